@@ -1,35 +1,89 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"github.com/krzysu/website-analyzer/internal/crawler"
 	"github.com/krzysu/website-analyzer/internal/database"
 	"github.com/krzysu/website-analyzer/internal/models"
+	"github.com/krzysu/website-analyzer/internal/netpolicy"
 	"github.com/krzysu/website-analyzer/internal/worker"
 )
 
-func AddURL(db *database.DB, jobQueue chan worker.Job) gin.HandlerFunc {
+// AddURL validates, normalizes and queues a URL for crawling. All invalid fields are reported
+// at once. URLs whose host is refused by policy are rejected; hosts resolving to refused
+// addresses are caught when the crawler connects.
+func AddURL(db *database.DB, jobQueue chan worker.Job, policy *netpolicy.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var json struct {
-			URL string `json:"url"`
+			URL             string          `json:"url"`
+			Tags            []string        `json:"tags"`
+			Analyzers       map[string]bool `json:"analyzers"`
+			ProbeResources  bool            `json:"probeResources"`
+			LinkScope       string          `json:"linkScope"`
+			InternalHosts   []string        `json:"internalHosts"`
+			BypassLinkCache bool            `json:"bypassLinkCache"`
+			InferScheme     bool            `json:"inferScheme"`
 		}
 		if err := c.ShouldBindJSON(&json); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
+		var errs []fieldError
+		fail := func(field, format string, args ...any) {
+			errs = append(errs, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+
+		targetURL := parseTargetURL(json.URL, json.InferScheme, policy, fail)
+		internalHosts := parseInternalHosts(json.InternalHosts, fail)
+
+		tagNames, err := normalizeTags(json.Tags)
+		if err != nil {
+			fail("tags", "%s", err.Error())
+		}
+
+		if err := validateAnalyzers(json.Analyzers); err != nil {
+			fail("analyzers", "%s", err.Error())
+		}
+
+		if err := validateLinkScope(json.LinkScope); err != nil {
+			fail("linkScope", "%s", err.Error())
+		}
+
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": errs})
+			return
+		}
+
 		// Create a new CrawlResult and save it with "queued" status
 		result := &models.CrawlResult{
 
-			URL:       json.URL,
-			Status:    "queued",
+			URL:    targetURL,
+			Status: "queued",
+			Tags:   make([]models.Tag, 0, len(tagNames)),
+			Options: models.CrawlOptions{
+				Analyzers:       json.Analyzers,
+				ProbeResources:  json.ProbeResources,
+				LinkScope:       json.LinkScope,
+				InternalHosts:   internalHosts,
+				BypassLinkCache: json.BypassLinkCache,
+			},
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
+		for _, name := range tagNames {
+			result.Tags = append(result.Tags, models.Tag{Name: name})
+		}
 		if err := db.CreateCrawlResult(result); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -44,20 +98,40 @@ func AddURL(db *database.DB, jobQueue chan worker.Job) gin.HandlerFunc {
 
 func GetURLs(db *database.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		query, errs := parseResultQuery(c.Request.URL.Query())
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": errs})
+			return
+		}
+
+		page, err := db.GetCrawlResultsPage(query)
+		if errors.Is(err, database.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": []fieldError{{Field: "cursor", Message: err.Error()}}})
 			return
 		}
-		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		sortBy := c.DefaultQuery("sortBy", "created_at")
-		filterBy := c.DefaultQuery("filterBy", "")
 
-		results, total, err := db.GetCrawlResultsAndTotal(limit, offset, sortBy, filterBy)
+		c.JSON(http.StatusOK, gin.H{
+			"results": page.Results,
+			"total":   page.Total,
+			"next":    optionalCursor(page.Next),
+			"prev":    optionalCursor(page.Prev),
+		})
+	}
+}
+
+func SearchURLs(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query, errs := parseSearchQuery(c.Request.URL.Query())
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": errs})
+			return
+		}
+
+		results, total, err := db.SearchCrawlResults(query)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -85,6 +159,65 @@ func GetURL(db *database.DB) gin.HandlerFunc {
 	}
 }
 
+func UpdateURL(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID parameter"})
+			return
+		}
+
+		var json struct {
+			Tags *[]string `json:"tags"`
+		}
+		if err := c.ShouldBindJSON(&json); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if json.Tags == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update: expected a tags field"})
+			return
+		}
+
+		tagNames, err := normalizeTags(*json.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := db.SetCrawlResultTags(uint(id), tagNames)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func GetTags(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counts, err := db.GetTagCounts()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"tags": counts})
+	}
+}
+
+func GetAnalyzers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"analyzers": crawler.DefaultRegistry.Analyzers()})
+	}
+}
+
 func DeleteURLs(db *database.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var json struct {
@@ -122,3 +255,63 @@ func RerunURLs(db *database.DB, jobQueue chan worker.Job) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Re-crawl initiated for selected URLs"})
 	}
 }
+
+const (
+	maxTagLength   = 100
+	maxTagsPerItem = 20
+)
+
+// tagPattern allows namespaced tags such as "client:acme" or "campaign:q4-2025".
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/-]*$`)
+
+// normalizeTags trims, lowercases and de-duplicates tag names, rejecting invalid ones.
+func normalizeTags(raw []string) ([]string, error) {
+	seen := make(map[string]bool, len(raw))
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q may only contain letters, digits and the characters _ . : / -", tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxTagsPerItem {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTagsPerItem)
+	}
+	return tags, nil
+}
+
+// validateAnalyzers checks that per-job analyzer overrides only name registered analyzers.
+func validateAnalyzers(overrides map[string]bool) error {
+	for name := range overrides {
+		if !crawler.DefaultRegistry.Has(name) {
+			return fmt.Errorf("unknown analyzer %q", name)
+		}
+	}
+	return nil
+}
+
+// validateLinkScope checks that a link scope is empty or one of the known scopes.
+func validateLinkScope(scope string) error {
+	switch scope {
+	case "", models.LinkScopeHost, models.LinkScopeSubdomains, models.LinkScopeDomain:
+		return nil
+	default:
+		return fmt.Errorf("unknown link scope %q", scope)
+	}
+}
+
+// optionalCursor renders an empty cursor as JSON null.
+func optionalCursor(cursor string) any {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
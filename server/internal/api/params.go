@@ -0,0 +1,334 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/database"
+	"github.com/krzysu/website-analyzer/internal/hostname"
+	"github.com/krzysu/website-analyzer/internal/netpolicy"
+)
+
+// fieldError describes a single invalid request field.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// failFunc records a validation error for a request field.
+type failFunc func(field, format string, args ...any)
+
+// maxListLimit is the largest page size a client may request.
+const maxListLimit = 100
+
+// maxURLLength is the longest URL accepted for crawling, the limit of most browsers and CDNs.
+const maxURLLength = 2048
+
+// resultStatuses are the statuses a crawl result can be in.
+var resultStatuses = map[string]bool{"queued": true, "running": true, "completed": true, "error": true}
+
+// listParams are the query parameters accepted by GET /urls.
+// sortBy and filterBy are kept as aliases of sort and url for existing clients.
+var listParams = map[string]bool{
+	"limit": true, "offset": true, "cursor": true, "sort": true, "sortBy": true, "url": true, "filterBy": true,
+	"title": true, "status": true, "htmlVersion": true, "hasLoginForm": true,
+	"brokenLinksMin": true, "brokenLinksMax": true,
+	"createdAfter": true, "createdBefore": true, "updatedAfter": true, "updatedBefore": true,
+	"tags": true, "tagMode": true,
+}
+
+// parseResultQuery validates the query parameters of a result listing and translates them into
+// a database query. All problems are reported at once rather than only the first one.
+func parseResultQuery(values url.Values) (database.ResultQuery, []fieldError) {
+	var errs []fieldError
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	checkUnknownParams(values, listParams, fail)
+
+	query := database.ResultQuery{}
+
+	query.Limit, query.Offset = parseLimitOffset(values, fail)
+	query.Cursor = values.Get("cursor")
+	if query.Cursor != "" && values.Get("offset") != "" {
+		fail("cursor", "cursor and offset cannot be combined")
+	}
+
+	sortParam, sortSpec := "sort", values.Get("sort")
+	if sortSpec == "" {
+		sortParam, sortSpec = "sortBy", values.Get("sortBy")
+	}
+	if sortSpec == "" {
+		sortSpec = "created_at"
+	}
+	sortFields, err := database.ParseSort(sortSpec)
+	if err != nil {
+		fail(sortParam, "%s", err.Error())
+	}
+	query.Sort = sortFields
+
+	query.Filter.URL = values.Get("url")
+	if query.Filter.URL == "" {
+		query.Filter.URL = values.Get("filterBy")
+	}
+	query.Filter.Title = values.Get("title")
+
+	for _, status := range splitList(values.Get("status")) {
+		if !resultStatuses[status] {
+			fail("status", "unknown status %q", status)
+		}
+		query.Filter.Statuses = append(query.Filter.Statuses, status)
+	}
+	query.Filter.HTMLVersions = splitList(values.Get("htmlVersion"))
+
+	if v := values.Get("hasLoginForm"); v != "" {
+		hasLoginForm, err := strconv.ParseBool(v)
+		if err != nil {
+			fail("hasLoginForm", "expected true or false")
+		}
+		query.Filter.HasLoginForm = &hasLoginForm
+	}
+
+	query.Filter.MinBrokenLinks = parseCount(values, "brokenLinksMin", fail)
+	query.Filter.MaxBrokenLinks = parseCount(values, "brokenLinksMax", fail)
+	if min, max := query.Filter.MinBrokenLinks, query.Filter.MaxBrokenLinks; min != nil && max != nil && *min > *max {
+		fail("brokenLinksMin", "must not be greater than brokenLinksMax")
+	}
+
+	query.Filter.CreatedAfter = parseTime(values, "createdAfter", fail)
+	query.Filter.CreatedBefore = parseTime(values, "createdBefore", fail)
+	query.Filter.UpdatedAfter = parseTime(values, "updatedAfter", fail)
+	query.Filter.UpdatedBefore = parseTime(values, "updatedBefore", fail)
+
+	tagFilter, err := parseTagFilter(values.Get("tags"), values.Get("tagMode"))
+	if err != nil {
+		fail("tags", "%s", err.Error())
+	}
+	query.Tags = tagFilter
+
+	return query, errs
+}
+
+// searchParams are the query parameters accepted by GET /urls/search.
+var searchParams = map[string]bool{"q": true, "in": true, "limit": true, "offset": true}
+
+// parseSearchQuery validates the query parameters of a full-text search.
+func parseSearchQuery(values url.Values) (database.SearchQuery, []fieldError) {
+	var errs []fieldError
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	checkUnknownParams(values, searchParams, fail)
+
+	query := database.SearchQuery{Text: strings.TrimSpace(values.Get("q"))}
+	if query.Text == "" {
+		fail("q", "a search query is required")
+	}
+
+	for _, field := range splitList(values.Get("in")) {
+		if !slices.Contains(database.SearchFields, field) {
+			fail("in", "unknown search field %q, expected one of %s", field, strings.Join(database.SearchFields, ", "))
+		}
+		query.Fields = append(query.Fields, field)
+	}
+
+	query.Limit, query.Offset = parseLimitOffset(values, fail)
+
+	return query, errs
+}
+
+// parseTagFilter parses the comma-separated tags query parameter and its match mode.
+func parseTagFilter(tagsParam, mode string) (database.TagFilter, error) {
+	var filter database.TagFilter
+	switch mode {
+	case "", "and":
+		filter.MatchAll = true
+	case "or":
+	default:
+		return filter, fmt.Errorf("invalid tagMode %q: expected \"and\" or \"or\"", mode)
+	}
+	if tagsParam == "" {
+		return filter, nil
+	}
+
+	names, err := normalizeTags(strings.Split(tagsParam, ","))
+	if err != nil {
+		return filter, err
+	}
+	filter.Names = names
+	return filter, nil
+}
+
+// parseTargetURL validates a URL submitted for crawling and returns it normalized: trimmed, with
+// a lowercase scheme and host, an internationalized host converted to punycode, and without a
+// default port or fragment. With inferScheme, a URL without a scheme such as "example.com/about"
+// is taken as https. Hosts refused by the network policy are rejected.
+func parseTargetURL(raw string, inferScheme bool, policy *netpolicy.Policy, fail failFunc) string {
+	valid := true
+	reject := func(format string, args ...any) {
+		fail("url", format, args...)
+		valid = false
+	}
+
+	raw = strings.TrimSpace(raw)
+	switch {
+	case raw == "":
+		reject("a URL is required")
+	case len(raw) > maxURLLength:
+		reject("must be at most %d characters long", maxURLLength)
+	case strings.ContainsAny(raw, " \t\r\n"):
+		reject("must not contain whitespace")
+	}
+	if !valid {
+		return ""
+	}
+
+	if inferScheme && !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		reject("invalid URL: %v", errors.Unwrap(err))
+		return ""
+	}
+	switch {
+	case u.Scheme == "" || u.Opaque != "":
+		reject("must be an absolute http or https URL such as https://example.com")
+	case u.Scheme != "http" && u.Scheme != "https":
+		reject("unsupported URL scheme %q, expected http or https", u.Scheme)
+	case u.Host == "":
+		reject("must have a host")
+	}
+	if !valid {
+		return ""
+	}
+
+	if u.User != nil {
+		reject("must not contain credentials")
+	}
+	host, err := hostname.ToASCII(u.Hostname())
+	if err != nil {
+		reject("invalid host %q: %v", u.Hostname(), err)
+	} else if err := policy.CheckHost(host); err != nil {
+		reject("URL not allowed: %v", err)
+	}
+	port := u.Port()
+	if n, err := strconv.Atoi(port); port != "" && (err != nil || n < 1 || n > 65535) {
+		reject("invalid port %q", port)
+	}
+	if !valid {
+		return ""
+	}
+
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u.String()
+}
+
+// parseInternalHosts validates the hosts treated as internal links and normalizes them like
+// the hosts of URLs, reporting every invalid entry.
+func parseInternalHosts(hosts []string, fail failFunc) []string {
+	var patterns []string
+	for _, host := range hosts {
+		pattern, err := hostname.ParsePattern(host)
+		if err != nil {
+			fail("internalHosts", "invalid host %q: %v", host, err)
+			continue
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// checkUnknownParams reports every query parameter that is not in the allowed set.
+func checkUnknownParams(values url.Values, allowed map[string]bool, fail failFunc) {
+	var unknown []string
+	for name := range values {
+		if !allowed[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fail(name, "unknown query parameter %q", name)
+	}
+}
+
+// parseLimitOffset parses the limit (default 10, at most maxListLimit) and offset (default 0) parameters.
+func parseLimitOffset(values url.Values, fail failFunc) (limit, offset int) {
+	limit = 10
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			fail("limit", "Invalid limit parameter: expected an integer between 1 and %d", maxListLimit)
+		}
+		limit = n
+	}
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fail("offset", "Invalid offset parameter: expected a non-negative integer")
+		}
+		offset = n
+	}
+	return limit, offset
+}
+
+// parseCount parses an optional non-negative integer query parameter.
+func parseCount(values url.Values, name string, fail failFunc) *int {
+	v := values.Get(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		fail(name, "expected a non-negative integer")
+		return nil
+	}
+	return &n
+}
+
+// parseTime parses an optional RFC 3339 timestamp or YYYY-MM-DD date query parameter.
+func parseTime(values url.Values, name string, fail failFunc) *time.Time {
+	v := values.Get(name)
+	if v == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t
+		}
+	}
+	fail(name, "expected an RFC 3339 timestamp or a YYYY-MM-DD date")
+	return nil
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
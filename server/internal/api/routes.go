@@ -3,14 +3,19 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/krzysu/website-analyzer/internal/database"
+	"github.com/krzysu/website-analyzer/internal/netpolicy"
 	"github.com/krzysu/website-analyzer/internal/worker"
 )
 
 func SetupRoutes(router *gin.Engine, db *database.DB, jobQueue chan worker.Job) {
 	// Pass the db instance to the handlers
-	router.POST("/urls", AddURL(db, jobQueue))
+	router.POST("/urls", AddURL(db, jobQueue, netpolicy.FromEnv()))
 	router.GET("/urls", GetURLs(db))
+	router.GET("/urls/search", SearchURLs(db))
 	router.GET("/urls/:id", GetURL(db))
+	router.PATCH("/urls/:id", UpdateURL(db))
 	router.DELETE("/urls", DeleteURLs(db))
 	router.POST("/urls/rerun", RerunURLs(db, jobQueue))
+	router.GET("/tags", GetTags(db))
+	router.GET("/analyzers", GetAnalyzers())
 }
@@ -0,0 +1,237 @@
+package crawler
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Accessibility rules, roughly following the corresponding WCAG 2.1 success criteria.
+const (
+	ruleImageMissingAlt     = "image_missing_alt"     // 1.1.1
+	ruleInputMissingLabel   = "input_missing_label"   // 1.3.1, 4.1.2
+	ruleLinkEmptyText       = "link_empty_text"       // 2.4.4
+	ruleLinkGenericText     = "link_generic_text"     // 2.4.4
+	ruleButtonMissingName   = "button_missing_name"   // 4.1.2
+	ruleHTMLMissingLang     = "html_missing_lang"     // 3.1.1
+	ruleDuplicateID         = "duplicate_id"          // 4.1.1
+	ruleTableMissingHeaders = "table_missing_headers" // 1.3.1
+	rulePositiveTabindex    = "positive_tabindex"     // 2.4.3
+)
+
+const (
+	// maxIssuesPerRule bounds the number of snippets stored per rule; counts stay exact.
+	maxIssuesPerRule = 10
+	// maxSnippetLength is the number of characters of markup kept per snippet.
+	maxSnippetLength = 200
+)
+
+// genericLinkTexts are link texts that do not describe the link target out of context.
+var genericLinkTexts = map[string]bool{
+	"click here": true, "here": true, "click": true, "more": true, "read more": true,
+	"learn more": true, "link": true, "this": true, "this link": true, "details": true, "more info": true,
+}
+
+// unlabelledInputTypes are input types that do not need a label.
+var unlabelledInputTypes = map[string]bool{
+	"hidden": true, "submit": true, "reset": true, "button": true, "image": true,
+}
+
+// accessibilityChecker walks a document and records accessibility issues.
+type accessibilityChecker struct {
+	report   models.AccessibilityReport
+	ids      map[string]*html.Node
+	labelled map[string]bool
+	seenIDs  map[string]int
+}
+
+// analyzeAccessibilityPage is the built-in "accessibility" analyzer.
+func analyzeAccessibilityPage(page *Page) (any, error) {
+	page.Result.Accessibility = analyzeAccessibility(page.Doc)
+	return nil, nil
+}
+
+// analyzeAccessibility runs the accessibility checks over a parsed document.
+func analyzeAccessibility(doc *html.Node) models.AccessibilityReport {
+	c := &accessibilityChecker{
+		report:   models.AccessibilityReport{Counts: map[string]int{}, Issues: []models.AccessibilityIssue{}},
+		ids:      map[string]*html.Node{},
+		labelled: map[string]bool{},
+		seenIDs:  map[string]int{},
+	}
+	c.index(doc)
+	c.check(doc, false)
+	return c.report
+}
+
+// index records element IDs and the IDs targeted by <label for>, which the checks look up.
+func (c *accessibilityChecker) index(n *html.Node) {
+	if n.Type == html.ElementNode {
+		if id := getAttr(n, "id"); id != "" {
+			if _, ok := c.ids[id]; !ok {
+				c.ids[id] = n
+			}
+		}
+		if n.Data == "label" && getAttr(n, "for") != "" {
+			c.labelled[getAttr(n, "for")] = true
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.index(child)
+	}
+}
+
+// check applies the rules to n and its descendants. insideLabel tells whether n is wrapped in a <label>.
+func (c *accessibilityChecker) check(n *html.Node, insideLabel bool) {
+	if n.Type == html.ElementNode {
+		c.checkElement(n, insideLabel)
+		insideLabel = insideLabel || n.Data == "label"
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.check(child, insideLabel)
+	}
+}
+
+func (c *accessibilityChecker) checkElement(n *html.Node, insideLabel bool) {
+	if id := getAttr(n, "id"); id != "" {
+		c.seenIDs[id]++
+		if c.seenIDs[id] == 2 {
+			c.add(ruleDuplicateID, n)
+		}
+	}
+	if tabindex, err := strconv.Atoi(strings.TrimSpace(getAttr(n, "tabindex"))); err == nil && tabindex > 0 {
+		c.add(rulePositiveTabindex, n)
+	}
+	if isHidden(n) {
+		return
+	}
+
+	switch n.Data {
+	case "html":
+		if strings.TrimSpace(getAttr(n, "lang")) == "" {
+			c.add(ruleHTMLMissingLang, n)
+		}
+	case "img":
+		if !hasAttr(n, "alt") && getAttr(n, "role") != "presentation" && getAttr(n, "role") != "none" {
+			c.add(ruleImageMissingAlt, n)
+		}
+	case "input":
+		inputType := strings.ToLower(getAttr(n, "type"))
+		switch inputType {
+		case "image":
+			if !hasAttr(n, "alt") && c.ariaName(n) == "" {
+				c.add(ruleImageMissingAlt, n)
+			}
+		case "button", "submit", "reset":
+			// Submit and reset buttons have a default name; plain buttons need a value
+			if inputType == "button" && strings.TrimSpace(getAttr(n, "value")) == "" && c.ariaName(n) == "" {
+				c.add(ruleButtonMissingName, n)
+			}
+		default:
+			if !unlabelledInputTypes[inputType] && !c.isLabelled(n, insideLabel) {
+				c.add(ruleInputMissingLabel, n)
+			}
+		}
+	case "select", "textarea":
+		if !c.isLabelled(n, insideLabel) {
+			c.add(ruleInputMissingLabel, n)
+		}
+	case "button":
+		if c.accessibleName(n) == "" {
+			c.add(ruleButtonMissingName, n)
+		}
+	case "a":
+		if !hasAttr(n, "href") {
+			return
+		}
+		name := c.accessibleName(n)
+		switch {
+		case name == "":
+			c.add(ruleLinkEmptyText, n)
+		case genericLinkTexts[strings.Trim(strings.ToLower(name), ".!:… ")]:
+			c.add(ruleLinkGenericText, n)
+		}
+	case "table":
+		if getAttr(n, "role") != "presentation" && getAttr(n, "role") != "none" && !hasDescendant(n, "th") {
+			c.add(ruleTableMissingHeaders, n)
+		}
+	}
+}
+
+// isLabelled reports whether a form control has a label, either by wrapping, <label for>, or ARIA.
+func (c *accessibilityChecker) isLabelled(n *html.Node, insideLabel bool) bool {
+	if insideLabel || c.ariaName(n) != "" {
+		return true
+	}
+	id := getAttr(n, "id")
+	return id != "" && c.labelled[id]
+}
+
+// accessibleName approximates the accessible name of an element from ARIA attributes,
+// its text and the alt text of contained images, and its title.
+func (c *accessibilityChecker) accessibleName(n *html.Node) string {
+	if name := c.ariaName(n); name != "" {
+		return name
+	}
+	if name := headingText(n); name != "" {
+		return name
+	}
+	return strings.TrimSpace(getAttr(n, "title"))
+}
+
+// ariaName returns the name given by aria-labelledby, aria-label or title.
+func (c *accessibilityChecker) ariaName(n *html.Node) string {
+	var parts []string
+	for _, id := range strings.Fields(getAttr(n, "aria-labelledby")) {
+		if target, ok := c.ids[id]; ok {
+			parts = append(parts, textContent(target))
+		}
+	}
+	if name := strings.TrimSpace(strings.Join(parts, " ")); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(getAttr(n, "aria-label")); name != "" {
+		return name
+	}
+	return strings.TrimSpace(getAttr(n, "title"))
+}
+
+// add records an offending element for a rule.
+func (c *accessibilityChecker) add(rule string, n *html.Node) {
+	c.report.Counts[rule]++
+	if c.report.Counts[rule] <= maxIssuesPerRule {
+		c.report.Issues = append(c.report.Issues, models.AccessibilityIssue{Rule: rule, Snippet: snippet(n)})
+	}
+}
+
+// isHidden reports whether an element is hidden from assistive technology.
+func isHidden(n *html.Node) bool {
+	return hasAttr(n, "hidden") || getAttr(n, "aria-hidden") == "true"
+}
+
+// hasDescendant reports whether n contains an element with the given tag.
+func hasDescendant(n *html.Node, tag string) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if (c.Type == html.ElementNode && c.Data == tag) || hasDescendant(c, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet renders the markup of an element, truncated to maxSnippetLength characters.
+func snippet(n *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, n); err != nil {
+		return "<" + n.Data + ">"
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	if utf8.RuneCountInString(s) <= maxSnippetLength {
+		return s
+	}
+	return string([]rune(s)[:maxSnippetLength]) + "…"
+}
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestAnalyzeAccessibility(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<!DOCTYPE html>
+<html><body>
+<img src="/a.png">
+<img src="/spacer.png" alt="">
+<img src="/b.png" aria-hidden="true">
+<label for="email">Email</label><input id="email" type="email">
+<label>Name <input type="text"></label>
+<input type="search" placeholder="Search">
+<input type="hidden" name="token">
+<span id="phone-label">Phone</span><input type="tel" aria-labelledby="phone-label">
+<textarea></textarea>
+<a href="/x"></a>
+<a href="/y"><img src="/icon.png" alt="Home"></a>
+<a href="/z">Click here</a>
+<a href="/w">Pricing</a>
+<button></button>
+<button aria-label="Close">×</button>
+<input type="button">
+<div id="dup"></div><div id="dup"></div><div id="dup"></div>
+<table><tr><td>1</td></tr></table>
+<table><tr><th>A</th></tr></table>
+<div tabindex="3"></div><div tabindex="0"></div>
+</body></html>`))
+	require.NoError(t, err)
+
+	report := analyzeAccessibility(doc)
+
+	assert.Equal(t, map[string]int{
+		ruleHTMLMissingLang:     1,
+		ruleImageMissingAlt:     1,
+		ruleInputMissingLabel:   2,
+		ruleLinkEmptyText:       1,
+		ruleLinkGenericText:     1,
+		ruleButtonMissingName:   2,
+		ruleDuplicateID:         1,
+		ruleTableMissingHeaders: 1,
+		rulePositiveTabindex:    1,
+	}, report.Counts)
+	require.Len(t, report.Issues, 11)
+	assert.Equal(t, ruleImageMissingAlt, report.Issues[1].Rule)
+	assert.Equal(t, `<img src="/a.png"/>`, report.Issues[1].Snippet)
+}
+
+func TestAnalyzeAccessibility_CapsIssues(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html lang="en"><body>` +
+		strings.Repeat(`<img src="/`+strings.Repeat("x", 300)+`.png">`, 15) + `</body></html>`))
+	require.NoError(t, err)
+
+	report := analyzeAccessibility(doc)
+
+	assert.Equal(t, 15, report.Counts[ruleImageMissingAlt])
+	require.Len(t, report.Issues, maxIssuesPerRule)
+	assert.True(t, strings.HasSuffix(report.Issues[0].Snippet, "…"))
+	assert.Equal(t, maxSnippetLength+1, len([]rune(report.Issues[0].Snippet)))
+}
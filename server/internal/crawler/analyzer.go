@@ -0,0 +1,244 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Page is a fetched and parsed page handed to analyzers.
+type Page struct {
+	// URL is the final URL of the page, after redirects.
+	URL *url.URL
+	// BaseURL is the URL relative references are resolved against: the <base href> of an
+	// HTML page that has one, otherwise URL.
+	BaseURL *url.URL
+	// Response is the HTTP response. Its body has already been read into Body.
+	Response *http.Response
+	// MediaType is the media type of the page, e.g. "text/html" or "application/pdf".
+	MediaType string
+	// Body is the decompressed response body of content other than HTML. HTML pages are parsed
+	// into Doc as they are read, transcoded to UTF-8, and have no Body; for other content Doc is nil.
+	Body []byte
+	Doc  *html.Node
+	// Result holds the core information extracted so far (title, headings, links, ...).
+	// Built-in analyzers record their findings on its dedicated fields and return no section.
+	Result *models.CrawlResult
+
+	// resources are the URLs referenced by an HTML page, for the built-in analyzers.
+	resources []resource
+}
+
+// Text returns the text of an HTML page with whitespace collapsed, or "" for other content.
+func (p *Page) Text() string {
+	if p.Doc == nil {
+		return ""
+	}
+	return textContent(p.Doc)
+}
+
+// Analyzer is a named check run on every crawled page.
+// The value returned by Analyze is stored as JSON in the result's section named
+// after the analyzer; a nil value stores no section.
+type Analyzer interface {
+	Name() string
+	Analyze(page *Page) (any, error)
+}
+
+// ContentTypeFilter is implemented by analyzers that choose the content they analyze.
+// Analyzers that do not implement it only run on HTML pages.
+type ContentTypeFilter interface {
+	Accepts(mediaType string) bool
+}
+
+// NewAnalyzer returns an Analyzer for HTML pages with the given name that calls fn.
+func NewAnalyzer(name string, fn func(page *Page) (any, error)) Analyzer {
+	return analyzerFunc{name: name, fn: fn}
+}
+
+// NewAnalyzerFor returns an Analyzer with the given name that calls fn for pages of the given
+// media types. A media type may end in a wildcard, e.g. "image/*", and "*/*" matches any content.
+func NewAnalyzerFor(name string, mediaTypes []string, fn func(page *Page) (any, error)) Analyzer {
+	return analyzerFunc{name: name, fn: fn, mediaTypes: mediaTypes}
+}
+
+type analyzerFunc struct {
+	name       string
+	fn         func(page *Page) (any, error)
+	mediaTypes []string
+}
+
+func (a analyzerFunc) Name() string                    { return a.name }
+func (a analyzerFunc) Analyze(page *Page) (any, error) { return a.fn(page) }
+
+func (a analyzerFunc) Accepts(mediaType string) bool {
+	if a.mediaTypes == nil {
+		return isHTMLMediaType(mediaType)
+	}
+	for _, accepted := range a.mediaTypes {
+		if accepted == mediaType || accepted == "*/*" || (strings.HasSuffix(accepted, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(accepted, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts reports whether an analyzer runs on pages of the given media type.
+func accepts(a Analyzer, mediaType string) bool {
+	if filter, ok := a.(ContentTypeFilter); ok {
+		return filter.Accepts(mediaType)
+	}
+	return isHTMLMediaType(mediaType)
+}
+
+// AnalyzerInfo describes a registered analyzer.
+type AnalyzerInfo struct {
+	Name             string `json:"name"`
+	EnabledByDefault bool   `json:"enabledByDefault"`
+}
+
+type registeredAnalyzer struct {
+	analyzer Analyzer
+	enabled  bool
+}
+
+// Registry is an ordered set of analyzers. Analyzers run in registration order.
+type Registry struct {
+	mu        sync.RWMutex
+	analyzers []registeredAnalyzer
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry holds the built-in analyzers. In-house analyzers can be added with Register.
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.mustRegister(NewAnalyzer("seo", analyzeSEO), true)
+	r.mustRegister(NewAnalyzer("structured_data", analyzeStructuredData), true)
+	r.mustRegister(NewAnalyzer("accessibility", analyzeAccessibilityPage), true)
+	r.mustRegister(NewAnalyzerFor("security", []string{"*/*"}, analyzeSecurity), true)
+	r.mustRegister(NewAnalyzerFor("pdf", []string{"application/pdf"}, analyzePDF), true)
+	r.mustRegister(NewAnalyzerFor("image", []string{"image/*"}, analyzeImage), true)
+	r.mustRegister(NewAnalyzerFor("text", textMediaTypes, analyzeText), true)
+	return r
+}
+
+// Register adds an analyzer to the DefaultRegistry.
+func Register(a Analyzer, enabledByDefault bool) error {
+	return DefaultRegistry.Register(a, enabledByDefault)
+}
+
+// Register adds an analyzer. Names must be unique within the registry.
+func (r *Registry) Register(a Analyzer, enabledByDefault bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a.Name() == "" {
+		return fmt.Errorf("analyzer name must not be empty")
+	}
+	for _, existing := range r.analyzers {
+		if existing.analyzer.Name() == a.Name() {
+			return fmt.Errorf("analyzer %q is already registered", a.Name())
+		}
+	}
+	r.analyzers = append(r.analyzers, registeredAnalyzer{analyzer: a, enabled: enabledByDefault})
+	return nil
+}
+
+func (r *Registry) mustRegister(a Analyzer, enabledByDefault bool) {
+	if err := r.Register(a, enabledByDefault); err != nil {
+		panic(err)
+	}
+}
+
+// Analyzers lists the registered analyzers.
+func (r *Registry) Analyzers() []AnalyzerInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]AnalyzerInfo, 0, len(r.analyzers))
+	for _, ra := range r.analyzers {
+		infos = append(infos, AnalyzerInfo{Name: ra.analyzer.Name(), EnabledByDefault: ra.enabled})
+	}
+	return infos
+}
+
+// Has reports whether an analyzer with the given name is registered.
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ra := range r.analyzers {
+		if ra.analyzer.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled returns the analyzers to run for a job, applying its per-analyzer overrides.
+func (r *Registry) Enabled(overrides map[string]bool) []Analyzer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var analyzers []Analyzer
+	for _, ra := range r.analyzers {
+		enabled := ra.enabled
+		if override, ok := overrides[ra.analyzer.Name()]; ok {
+			enabled = override
+		}
+		if enabled {
+			analyzers = append(analyzers, ra.analyzer)
+		}
+	}
+	return analyzers
+}
+
+// Run runs the enabled analyzers that accept the page's media type and stores their sections
+// on page.Result. A failing analyzer does not fail the crawl; its error is recorded in its section.
+func (r *Registry) Run(page *Page, overrides map[string]bool) {
+	sections := make(models.Sections)
+	for _, a := range r.Enabled(overrides) {
+		if !accepts(a, page.MediaType) {
+			continue
+		}
+		data, err := runAnalyzer(a, page)
+		if err != nil {
+			log.Printf("Analyzer %s failed for %s: %v\n", a.Name(), page.Result.URL, err)
+			sections[a.Name()] = models.Section{Error: err.Error()}
+			continue
+		}
+		if data == nil {
+			continue
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			sections[a.Name()] = models.Section{Error: err.Error()}
+			continue
+		}
+		sections[a.Name()] = models.Section{Data: raw}
+	}
+	page.Result.Sections = sections
+}
+
+// runAnalyzer calls an analyzer, turning a panic into an error.
+func runAnalyzer(a Analyzer, page *Page) (data any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("analyzer panicked: %v", r)
+		}
+	}()
+	return a.Analyze(page)
+}
@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestRegistry(t *testing.T) {
+	type wordCount struct {
+		Words int `json:"words"`
+	}
+
+	r := NewRegistry()
+	require.NoError(t, r.Register(NewAnalyzer("words", func(page *Page) (any, error) {
+		return wordCount{Words: len(strings.Fields(page.Text()))}, nil
+	}), true))
+	require.NoError(t, r.Register(NewAnalyzer("failing", func(page *Page) (any, error) {
+		return nil, errors.New("boom")
+	}), true))
+	require.NoError(t, r.Register(NewAnalyzer("panicking", func(page *Page) (any, error) {
+		panic("oops")
+	}), true))
+	require.NoError(t, r.Register(NewAnalyzer("optional", func(page *Page) (any, error) {
+		return "ran", nil
+	}), false))
+
+	assert.Error(t, r.Register(NewAnalyzer("words", nil), true))
+	assert.Error(t, r.Register(NewAnalyzer("", nil), true))
+	assert.True(t, r.Has("optional"))
+	assert.False(t, r.Has("missing"))
+	assert.Equal(t, AnalyzerInfo{Name: "optional", EnabledByDefault: false}, r.Analyzers()[3])
+
+	doc, err := html.Parse(strings.NewReader(`<p>three little words</p>`))
+	require.NoError(t, err)
+	page := &Page{MediaType: "text/html", Doc: doc, Result: &models.CrawlResult{URL: "http://example.com"}}
+
+	r.Run(page, nil)
+	assert.JSONEq(t, `{"words": 3}`, string(page.Result.Sections["words"].Data))
+	assert.Equal(t, "boom", page.Result.Sections["failing"].Error)
+	assert.Contains(t, page.Result.Sections["panicking"].Error, "oops")
+	assert.NotContains(t, page.Result.Sections, "optional")
+
+	// Per-job overrides enable and disable analyzers
+	r.Run(page, map[string]bool{"optional": true, "failing": false, "panicking": false})
+	assert.JSONEq(t, `"ran"`, string(page.Result.Sections["optional"].Data))
+	assert.NotContains(t, page.Result.Sections, "failing")
+	assert.Len(t, page.Result.Sections, 2)
+}
+
+func TestCrawl_DisabledAnalyzers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, err := w.Write([]byte(`<!DOCTYPE html>
+<html><head><title>Test Page</title><meta name="description" content="Description"></head>
+<body><img src="/a.png"></body></html>`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{
+		URL:      ts.URL,
+		Headings: make(map[string]int),
+		Options:  models.CrawlOptions{Analyzers: map[string]bool{"seo": false}},
+	}
+
+	require.NoError(t, Crawl(result))
+	assert.Equal(t, "Test Page", result.PageTitle)
+	assert.Equal(t, "Description", result.MetaDescription) // core information, searched without the seo analyzer
+	assert.Nil(t, result.SEOFindings)
+	assert.Equal(t, 1, result.Accessibility.Counts[ruleImageMissingAlt])
+
+	// Built-in analyzers store their reports in dedicated fields only, not again as sections
+	assert.Empty(t, result.Sections)
+}
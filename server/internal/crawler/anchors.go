@@ -0,0 +1,163 @@
+package crawler
+
+import (
+	"bufio"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Limits on the internal pages fetched to validate link fragments.
+const (
+	maxAnchorTargets        = 20
+	anchorTargetConcurrency = 4
+)
+
+// checkAnchors verifies that the fragments of same-page and internal links, in the link scope of
+// the job, name an element of the target page, by id or, for <a> elements, by name. Same-page
+// fragments are looked up in doc; every other internal page is fetched once, up to
+// maxAnchorTargets pages. Links whose fragment matches no element are recorded as dangling
+// anchors. Targets that cannot be fetched or are not HTML are skipped; broken targets are
+// already reported by the link checker.
+func (c *Crawler) checkAnchors(links []string, pageURL *url.URL, doc *html.Node, result *models.CrawlResult) {
+	result.DanglingAnchors = make([]map[string]any, 0)
+
+	// Fragments are resolved against the submitted URL, which may differ from the final one
+	samePage := map[string]bool{withoutFragment(pageURL): true}
+	if submitted, err := url.Parse(result.URL); err == nil {
+		samePage[withoutFragment(submitted)] = true
+	}
+
+	type anchorLink struct {
+		url      string
+		target   string
+		fragment string
+	}
+	scope := newLinkScope(pageURL, result.Options)
+	var toCheck []anchorLink
+	var targets []string
+	anchors := map[string]map[string]bool{}
+	seen := map[string]bool{}
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil || seen[link] || !checkableFragment(u.Fragment) || !scope.isInternal(u.Hostname()) {
+			continue
+		}
+		seen[link] = true
+		target := withoutFragment(u)
+		if _, ok := anchors[target]; !ok {
+			switch {
+			case samePage[target]:
+				anchors[target] = collectAnchors(doc)
+			case len(targets) < maxAnchorTargets:
+				anchors[target] = nil
+				targets = append(targets, target)
+			default:
+				continue
+			}
+		}
+		toCheck = append(toCheck, anchorLink{url: link, target: target, fragment: u.Fragment})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, anchorTargetConcurrency)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			ids := c.fetchAnchors(target)
+			mu.Lock()
+			anchors[target] = ids
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	for _, link := range toCheck {
+		ids, ok := anchors[link.target]
+		if !ok || ids == nil {
+			continue
+		}
+		if !ids[link.fragment] {
+			result.DanglingAnchors = append(result.DanglingAnchors, map[string]any{"url": link.url, "fragment": link.fragment})
+		}
+	}
+	log.Printf("Found %d dangling anchors.\n", len(result.DanglingAnchors))
+}
+
+// fetchAnchors fetches an HTML page and returns the ids and anchor names of its elements, or
+// nil if the page cannot be fetched or is not HTML.
+func (c *Crawler) fetchAnchors(target string) map[string]bool {
+	resp, err := c.linkClient.Get(target)
+	if err != nil {
+		log.Printf("Error fetching anchor target %s: %v\n", target, err)
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+	body, err := openBody(resp, c.config.MaxBodySize)
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	reader := bufio.NewReaderSize(body, sniffLength)
+	prefix, _ := reader.Peek(sniffLength)
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTMLMediaType(mediaTypeOf(contentType, prefix)) {
+		return nil
+	}
+	// The encoding findings of the target page are not reported
+	doc, err := html.Parse(decodeBody(reader, contentType, &models.CrawlResult{}))
+	if err != nil {
+		return nil
+	}
+	return collectAnchors(doc)
+}
+
+// collectAnchors returns the fragments that scroll to an element of a document: the ids of all
+// elements and the names of <a> elements.
+func collectAnchors(doc *html.Node) map[string]bool {
+	anchors := map[string]bool{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id := getAttr(n, "id"); id != "" {
+				anchors[id] = true
+			}
+			if n.Data == "a" && getAttr(n, "name") != "" {
+				anchors[getAttr(n, "name")] = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return anchors
+}
+
+// checkableFragment reports whether a fragment is expected to name an element. An empty fragment
+// and "top" scroll to the top of the page; text fragments (":~:") and client-side routes
+// ("#!/..." or "#/...") do not refer to elements.
+func checkableFragment(fragment string) bool {
+	return fragment != "" && !strings.EqualFold(fragment, "top") &&
+		!strings.HasPrefix(fragment, ":~:") && !strings.HasPrefix(fragment, "!") && !strings.HasPrefix(fragment, "/")
+}
+
+// withoutFragment returns u as a string without its fragment.
+func withoutFragment(u *url.URL) string {
+	stripped := *u
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+	return stripped.String()
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestCrawl_DanglingAnchors(t *testing.T) {
+	var docsFetches atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html>
+<html><body>
+<h2 id="intro">Intro</h2>
+<a name="legacy"></a>
+<a href="#intro">Present</a>
+<a href="#legacy">Named anchor</a>
+<a href="#missing">Missing</a>
+<a href="#caf%C3%A9">Encoded</a>
+<a href="#top">Top</a>
+<a href="#">Empty</a>
+<a href="#!/route">Client-side route</a>
+<a href="/docs#install">Docs install</a>
+<a href="/docs#gone">Docs gone</a>
+<a href="/docs#gone">Docs gone again</a>
+<a href="/docs#:~:text=install">Text fragment</a>
+<a href="/manual.pdf#page=2">PDF page</a>
+<a href="/missing#section">Broken target</a>
+<a href="http://other.example.com/#nowhere">External</a>
+</body></html>`))
+			assert.NoError(t, err)
+		case "/docs":
+			if r.Method == http.MethodGet {
+				docsFetches.Add(1)
+			}
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html><html><body><section id="install">Install</section></body></html>`))
+			assert.NoError(t, err)
+		case "/manual.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, err := w.Write([]byte("%PDF-1.7"))
+			assert.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL + "/", Headings: make(map[string]int)}
+	require.NoError(t, Crawl(result))
+
+	assert.Equal(t, models.JSONArray{
+		{"url": ts.URL + "/#missing", "fragment": "missing"},
+		{"url": ts.URL + "/#caf%C3%A9", "fragment": "café"},
+		{"url": ts.URL + "/docs#gone", "fragment": "gone"},
+	}, result.DanglingAnchors)
+	assert.Equal(t, int32(1), docsFetches.Load())
+}
+
+func TestCheckableFragment(t *testing.T) {
+	for fragment, want := range map[string]bool{
+		"install":       true,
+		"":              false,
+		"top":           false,
+		"TOP":           false,
+		":~:text=hello": false,
+		"!/users/1":     false,
+		"/settings":     false,
+	} {
+		assert.Equal(t, want, checkableFragment(fragment), fragment)
+	}
+}
+
+func TestCrawl_DanglingAnchorsInScope(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html><html><body>
+<a href="` + strings.Replace(ts.URL, "127.0.0.1", "localhost", 1) + `/docs#gone">Docs on another host</a>
+<a href="/legacy#caf%C3%A9">Legacy page</a>
+</body></html>`))
+			assert.NoError(t, err)
+		case "/docs":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html><html><body></body></html>`))
+			assert.NoError(t, err)
+		case "/legacy":
+			// Undeclared windows-1252, detected like the crawled page itself
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte("<!DOCTYPE html><html><body><h2 id=\"caf\xe9\">Caf\xe9</h2></body></html>"))
+			assert.NoError(t, err)
+		}
+	}))
+	defer ts.Close()
+
+	// Links to another host are only checked when the host is internal to the job
+	result := &models.CrawlResult{URL: ts.URL + "/", Headings: make(map[string]int)}
+	require.NoError(t, Crawl(result))
+	assert.Empty(t, result.DanglingAnchors)
+
+	result = &models.CrawlResult{URL: ts.URL + "/", Headings: make(map[string]int), Options: models.CrawlOptions{InternalHosts: []string{"localhost"}}}
+	require.NoError(t, Crawl(result))
+	assert.Equal(t, models.JSONArray{
+		{"url": strings.Replace(ts.URL, "127.0.0.1", "localhost", 1) + "/docs#gone", "fragment": "gone"},
+	}, result.DanglingAnchors)
+}
@@ -0,0 +1,42 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// effectiveBaseURL returns the URL the relative references of a document are resolved against,
+// per the HTML specification: the href of the first <base> element that has one, resolved
+// against the document URL, or else the document URL itself. Browsers ignore a base href that
+// cannot be parsed, and so does this; it is reported, as are further <base> elements.
+func effectiveBaseURL(doc *html.Node, docURL *url.URL) (*url.URL, models.FindingList) {
+	findings := models.FindingList{}
+	var hrefs []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "base" && hasAttr(n, "href") {
+			hrefs = append(hrefs, getAttr(n, "href"))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if len(hrefs) == 0 {
+		return docURL, findings
+	}
+	if len(hrefs) > 1 {
+		findings = append(findings, models.Finding{Code: "base_href_multiple", Severity: models.SeverityWarning, Message: fmt.Sprintf("The page has %d <base> elements with an href; only the first is used", len(hrefs))})
+	}
+	ref, err := url.Parse(strings.TrimSpace(hrefs[0]))
+	if err != nil {
+		findings = append(findings, models.Finding{Code: "base_href_invalid", Severity: models.SeverityError, Message: fmt.Sprintf("<base href=%q> cannot be parsed, so links are resolved against the page URL: %v", hrefs[0], err)})
+		return docURL, findings
+	}
+	return docURL.ResolveReference(ref), findings
+}
@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestEffectiveBaseURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		head  string
+		base  string
+		codes []string
+	}{
+		{name: "no base element", head: ``, base: "https://example.com/docs/page.html", codes: []string{}},
+		{name: "base without href", head: `<base target="_blank">`, base: "https://example.com/docs/page.html", codes: []string{}},
+		{name: "relative base", head: `<base href="/static/">`, base: "https://example.com/static/", codes: []string{}},
+		{name: "absolute base", head: `<base href="https://cdn.example.net/v2/">`, base: "https://cdn.example.net/v2/", codes: []string{}},
+		{name: "first base wins", head: `<base href="/first/"><base href="/second/">`, base: "https://example.com/first/", codes: []string{"base_href_multiple"}},
+		{name: "malformed base", head: `<base href="http://exa mple.com/">`, base: "https://example.com/docs/page.html", codes: []string{"base_href_invalid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tt.head + "</head></html>"))
+			require.NoError(t, err)
+
+			base, findings := effectiveBaseURL(doc, mustParseURL(t, "https://example.com/docs/page.html"))
+
+			assert.Equal(t, tt.base, base.String())
+			codes := []string{}
+			for _, f := range findings {
+				codes = append(codes, f.Code)
+			}
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+}
+
+func TestCrawl_BaseHref(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/docs/guide/", http.StatusMovedPermanently)
+		case "/docs/guide/":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html>
+<html><head>
+<base href="../assets/">
+<link rel="canonical" href="guide.html">
+<link rel="stylesheet" href="style.css">
+</head><body>
+<a href="intro.html">Relative to base</a>
+<a href="http://exa mple.com/">Malformed</a>
+<a href="https:">No host</a>
+<a href="/absolute-path">Absolute path</a>
+<img src="logo.png">
+<form action="submit"></form>
+</body></html>`))
+			assert.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL + "/old", Headings: make(map[string]int)}
+	require.NoError(t, Crawl(result))
+
+	assert.Equal(t, ts.URL+"/docs/assets/", result.BaseURL)
+	assert.Equal(t, ts.URL+"/docs/assets/guide.html", result.CanonicalURL)
+	assert.Equal(t, ts.URL+"/docs/assets/submit", result.Forms.Forms[0].Action)
+	assert.Equal(t, []models.Resource{
+		{URL: ts.URL + "/docs/assets/style.css", Kind: "stylesheet"},
+		{URL: ts.URL + "/docs/assets/logo.png", Kind: "image"},
+	}, result.Performance.Resources)
+
+	// Links after a malformed href are still extracted
+	assert.Equal(t, 2, result.InternalLinksCount)
+	assert.Equal(t, 0, result.InaccessibleLinksCount)
+	require.Len(t, result.LinkFindings, 2)
+	assert.Equal(t, "href_unresolvable", result.LinkFindings[0].Code)
+	assert.Contains(t, result.LinkFindings[0].Message, `"http://exa mple.com/"`)
+	assert.Equal(t, "href_unresolvable", result.LinkFindings[1].Code)
+	assert.Contains(t, result.LinkFindings[1].Message, "no host")
+}
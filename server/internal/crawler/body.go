@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding lists the content codings the crawler can decompress.
+const acceptEncoding = "gzip, deflate, br"
+
+// bodyReader streams a response body, decompressing it according to its Content-Encoding.
+// At most maxSize bytes are read, both from the wire and after decompression, so neither a
+// huge response nor a decompression bomb can exhaust memory; a longer body ends early and is
+// reported as truncated.
+type bodyReader struct {
+	wire      *io.LimitedReader
+	wireLimit int64
+	decoded   io.Reader
+	remaining int64 // decoded bytes still allowed
+	size      int64 // decoded bytes read
+	truncated bool
+	doneAt    time.Time // when the end of the body was reached
+}
+
+// openBody starts reading the body of resp, up to maxSize bytes.
+func openBody(resp *http.Response, maxSize int64) (*bodyReader, error) {
+	// Bound the compressed input as well as the output
+	wire := &io.LimitedReader{R: resp.Body, N: maxSize + 1}
+	decoded, err := decompress(wire, resp.Header.Get("Content-Encoding"))
+	if err == io.EOF {
+		decoded, err = bytes.NewReader(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bodyReader{wire: wire, wireLimit: maxSize + 1, decoded: decoded, remaining: maxSize}, nil
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, b.finish()
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.decoded.Read(p)
+	b.size += int64(n)
+	b.remaining -= int64(n)
+	switch {
+	case err == io.EOF:
+		b.truncated = b.wire.N == 0
+		b.doneAt = time.Now()
+	case err != nil && b.wire.N == 0:
+		// A compressed stream cut off by the wire limit fails to decode; keep what was decoded
+		b.truncated = true
+		b.doneAt = time.Now()
+		err = io.EOF
+	}
+	return n, err
+}
+
+// finish ends a body that reached the size limit, checking whether it goes on beyond it.
+func (b *bodyReader) finish() error {
+	if b.doneAt.IsZero() {
+		n, err := io.ReadFull(b.decoded, make([]byte, 1))
+		if n == 0 && err != io.EOF && err != io.ErrUnexpectedEOF && b.wire.N > 0 {
+			return err
+		}
+		b.truncated = n > 0 || b.wire.N == 0
+		b.doneAt = time.Now()
+	}
+	return io.EOF
+}
+
+// Close releases the decompressor.
+func (b *bodyReader) Close() error {
+	if closer, ok := b.decoded.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// transferSize returns the number of bytes read from the wire.
+func (b *bodyReader) transferSize() int64 {
+	return b.wireLimit - b.wire.N
+}
+
+// decompress wraps r in a decoder for the given Content-Encoding.
+func decompress(r io.Reader, contentEncoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// "deflate" should be zlib-wrapped, but some servers send a raw deflate stream
+		br := bufio.NewReader(r)
+		header, err := br.Peek(2)
+		if err == nil && isZlibHeader(header) {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case "br":
+		return brotli.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", contentEncoding)
+	}
+}
+
+// isZlibHeader reports whether b starts with a zlib header using the deflate method.
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0F == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+const compressedPage = `<!DOCTYPE html><html><head><title>Compressed Page</title></head><body></body></html>`
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		w = fw
+	case "br":
+		w = brotli.NewWriter(&buf)
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCrawl_CompressedResponses(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "br"} {
+		t.Run(encoding, func(t *testing.T) {
+			body := compress(t, encoding, []byte(compressedPage))
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, acceptEncoding, r.Header.Get("Accept-Encoding"))
+				w.Header().Set("Content-Type", "text/html")
+				w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+				_, _ = w.Write(body)
+			}))
+			defer ts.Close()
+
+			result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+			require.NoError(t, newTestCrawler(Config{}).Crawl(result))
+
+			assert.Equal(t, "Compressed Page", result.PageTitle)
+			assert.Equal(t, int64(len(compressedPage)), result.ContentSize)
+			assert.False(t, result.BodyTruncated)
+		})
+	}
+}
+
+func TestCrawl_MaxBodySize(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Large Page</title></head><body>` + strings.Repeat("<p>filler</p>", 1000) + `</body></html>`
+	bomb := compress(t, "gzip", make([]byte, 50<<20))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/bomb":
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(bomb)
+		case "/unsupported":
+			w.Header().Set("Content-Encoding", "compress")
+			_, _ = w.Write([]byte(page))
+		default:
+			_, _ = w.Write([]byte(page))
+		}
+	}))
+	defer ts.Close()
+
+	c := newTestCrawler(Config{MaxBodySize: 1024})
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, c.Crawl(result))
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, "Large Page", result.PageTitle)
+	assert.Equal(t, int64(1024), result.ContentSize)
+	assert.True(t, result.BodyTruncated)
+
+	// A decompression bomb is cut off at the limit
+	result = &models.CrawlResult{URL: ts.URL + "/bomb", Headings: make(map[string]int)}
+	require.NoError(t, c.Crawl(result))
+	assert.Equal(t, int64(1024), result.ContentSize)
+	assert.True(t, result.BodyTruncated)
+
+	result = &models.CrawlResult{URL: ts.URL + "/unsupported", Headings: make(map[string]int)}
+	assert.EqualError(t, c.Crawl(result), `unsupported Content-Encoding "compress"`)
+	assert.Equal(t, "error", result.Status)
+
+	// Bodies within the limit are not truncated
+	result = &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, newTestCrawler(Config{MaxBodySize: int64(len(page))}).Crawl(result))
+	assert.False(t, result.BodyTruncated)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CRAWLER_MAX_BODY_SIZE", "2048")
+	assert.Equal(t, int64(2048), ConfigFromEnv().MaxBodySize)
+
+	t.Setenv("CRAWLER_MAX_BODY_SIZE", "lots")
+	assert.Equal(t, int64(DefaultMaxBodySize), ConfigFromEnv().MaxBodySize)
+}
+
+func TestOpenBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		encoding  string
+		body      []byte
+		limit     int64
+		size      int64
+		truncated bool
+	}{
+		{name: "within the limit", body: []byte("0123456789"), limit: 10, size: 10},
+		{name: "beyond the limit", body: []byte("0123456789abcdef"), limit: 10, size: 10, truncated: true},
+		{name: "empty gzip", encoding: "gzip", limit: 10, size: 0},
+		{name: "decompression bomb", encoding: "gzip", body: compress(t, "gzip", make([]byte, 1<<20)), limit: 100, size: 100, truncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+			resp.Header.Set("Content-Encoding", tt.encoding)
+			body, err := openBody(resp, tt.limit)
+			require.NoError(t, err)
+			defer body.Close()
+
+			// Read in small chunks, as the HTML tokenizer does
+			data, err := io.ReadAll(iotest.OneByteReader(body))
+			require.NoError(t, err)
+			assert.Len(t, data, int(tt.size))
+			assert.Equal(t, tt.size, body.size)
+			assert.Equal(t, tt.truncated, body.truncated)
+			assert.False(t, body.doneAt.IsZero())
+			assert.LessOrEqual(t, body.transferSize(), tt.limit+1)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"github.com/krzysu/website-analyzer/internal/netpolicy"
+)
+
+// DefaultMaxBodySize is the default limit on the size of a response body, after decompression.
+const DefaultMaxBodySize = 10 << 20 // 10 MiB
+
+// DefaultCertExpiryWarningDays is the default number of days before a certificate expires
+// from which its expiry is reported.
+const DefaultCertExpiryWarningDays = 30
+
+// Timeouts of the request for the page and of each request for a link, resource or anchor target,
+// so that a stalled server cannot hold a worker indefinitely.
+const (
+	pageTimeout       = 30 * time.Second
+	subrequestTimeout = 10 * time.Second
+)
+
+// DefaultLinkCacheTTL is the default time the status of a checked link is reused for.
+const DefaultLinkCacheTTL = 15 * time.Minute
+
+// Config holds the crawler settings.
+type Config struct {
+	// MaxBodySize is the maximum number of bytes read from a response body, after
+	// decompression. Longer bodies are truncated.
+	MaxBodySize int64
+	// CertExpiryWarningDays is the number of days before the expiry of a TLS certificate
+	// from which a warning is reported.
+	CertExpiryWarningDays int
+	// LinkCacheTTL is how long the status of a checked link or resource is reused, across
+	// pages and jobs. Zero means DefaultLinkCacheTTL; a negative value disables the cache.
+	LinkCacheTTL time.Duration
+	// LinkCacheDB adds the database as a second tier of the link cache, shared between
+	// processes. It is applied by the caller with SetLinkStatusStore.
+	LinkCacheDB bool
+	// NetworkPolicy restricts the addresses that pages, links and resources are fetched from.
+	// Nil means netpolicy.Default, which blocks private, loopback and metadata addresses.
+	NetworkPolicy *netpolicy.Policy
+}
+
+// ConfigFromEnv reads the crawler settings from the environment:
+//
+//   - CRAWLER_MAX_BODY_SIZE: maximum response body size in bytes (default 10 MiB)
+//   - CRAWLER_CERT_EXPIRY_WARNING_DAYS: days before certificate expiry to warn from (default 30)
+//   - CRAWLER_LINK_CACHE_TTL: how long link statuses are cached, e.g. "15m"; "0" disables the cache
+//   - CRAWLER_LINK_CACHE_DB: "true" to share cached link statuses through the database
+//   - NETWORK_ALLOWLIST and NETWORK_DENYLIST: the network policy, see netpolicy.FromEnv
+func ConfigFromEnv() Config {
+	linkCacheTTL := envDuration("CRAWLER_LINK_CACHE_TTL", DefaultLinkCacheTTL)
+	if linkCacheTTL == 0 {
+		linkCacheTTL = -1
+	}
+	return Config{
+		MaxBodySize:           envInt64("CRAWLER_MAX_BODY_SIZE", DefaultMaxBodySize),
+		CertExpiryWarningDays: int(envInt64("CRAWLER_CERT_EXPIRY_WARNING_DAYS", DefaultCertExpiryWarningDays)),
+		LinkCacheTTL:          linkCacheTTL,
+		LinkCacheDB:           envBool("CRAWLER_LINK_CACHE_DB"),
+		NetworkPolicy:         netpolicy.FromEnv(),
+	}
+}
+
+// envInt64 reads a positive integer from the environment, falling back to def if it is unset or invalid.
+func envInt64(key string, def int64) int64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value <= 0 {
+		log.Printf("Invalid %s %q, using %d", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// envDuration reads a non-negative duration from the environment, falling back to def if it is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		log.Printf("Invalid %s %q, using %s", key, raw, def)
+		return def
+	}
+	return value
+}
+
+// envBool reads a boolean from the environment; it is false if unset or invalid.
+func envBool(key string) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Invalid %s %q, using false", key, raw)
+	}
+	return value
+}
+
+// Crawler fetches and analyzes pages.
+type Crawler struct {
+	config     Config
+	client     *http.Client // fetches the page only, accepting invalid certificates for the TLS audit
+	linkClient *http.Client // fetches links, resources and anchor targets, verifying certificates
+	registry   *Registry
+	roots      *x509.CertPool // trusted roots for certificate validation; nil means the system roots
+	linkCache  *LinkCache     // nil if disabled
+}
+
+// New creates a Crawler running the analyzers of the DefaultRegistry.
+func New(config Config) *Crawler {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+	if config.CertExpiryWarningDays <= 0 {
+		config.CertExpiryWarningDays = DefaultCertExpiryWarningDays
+	}
+	if config.LinkCacheTTL == 0 {
+		config.LinkCacheTTL = DefaultLinkCacheTTL
+	}
+	if config.NetworkPolicy == nil {
+		config.NetworkPolicy = netpolicy.Default()
+	}
+	// Certificates of the page are verified after the handshake by inspectTLS, so that pages with
+	// an invalid certificate are still analyzed and the validation errors are recorded. Every other
+	// request verifies certificates, as a link with an invalid certificate is broken.
+	transport := newTransport(config.NetworkPolicy)
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	c := &Crawler{
+		config:     config,
+		client:     &http.Client{Transport: transport, Timeout: pageTimeout},
+		linkClient: &http.Client{Transport: newTransport(config.NetworkPolicy), Timeout: subrequestTimeout},
+		registry:   DefaultRegistry,
+	}
+	if config.LinkCacheTTL > 0 {
+		c.linkCache = NewLinkCache(config.LinkCacheTTL, nil)
+	}
+	return c
+}
+
+// newTransport creates an HTTP transport dialing through policy. Proxies are not used, as the
+// policy would only apply to the proxy's address.
+func newTransport(policy *netpolicy.Policy) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = policy.DialContext
+	return transport
+}
+
+// SetLinkStatusStore adds a persistent tier to the link cache. It must be called before
+// the crawler is used and has no effect if the cache is disabled.
+func (c *Crawler) SetLinkStatusStore(store LinkStatusStore) {
+	if c.linkCache != nil {
+		c.linkCache.store = store
+	}
+}
+
+// LinkCache returns the link status cache of the crawler, or nil if it is disabled.
+func (c *Crawler) LinkCache() *LinkCache {
+	return c.linkCache
+}
+
+// Crawl crawls a single URL with the settings from the environment.
+func Crawl(result *models.CrawlResult) error {
+	return New(ConfigFromEnv()).Crawl(result)
+}
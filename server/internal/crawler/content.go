@@ -0,0 +1,209 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	_ "image/gif"  // register the GIF decoder for analyzeImage
+	_ "image/jpeg" // register the JPEG decoder for analyzeImage
+	_ "image/png"  // register the PNG decoder for analyzeImage
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+// textMediaTypes are the non-HTML media types handled by the "text" analyzer.
+var textMediaTypes = []string{"text/plain", "text/csv", "text/markdown", "application/json", "text/json"}
+
+var (
+	pdfVersionPattern   = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+	pdfPageCountPattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPagePattern      = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfEncryptPattern   = regexp.MustCompile(`/Encrypt\s`)
+)
+
+// mediaTypeOf returns the media type of a response from its Content-Type header, sniffing the
+// body when the header is missing or generic.
+func mediaTypeOf(contentType string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	return mediaType
+}
+
+// isHTMLMediaType reports whether a media type is parsed as HTML.
+func isHTMLMediaType(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// analyzePDF is the built-in "pdf" analyzer. It reads the PDF version, page count and document
+// information without a full PDF parser, so documents with compressed object streams may report
+// no pages.
+func analyzePDF(page *Page) (any, error) {
+	body := page.Body
+	info := models.PDFInfo{Encrypted: pdfEncryptPattern.Match(body)}
+
+	if match := pdfVersionPattern.FindSubmatch(body); match != nil {
+		info.Version = string(match[1])
+	}
+
+	// Prefer the page tree's count; count the page objects if it is not readable
+	for _, match := range pdfPageCountPattern.FindAllSubmatch(body, -1) {
+		count := match[1]
+		if count == nil {
+			count = match[2]
+		}
+		if n, err := strconv.Atoi(string(count)); err == nil && n > info.PageCount {
+			info.PageCount = n
+		}
+	}
+	if info.PageCount == 0 {
+		info.PageCount = len(pdfPagePattern.FindAll(body, -1))
+	}
+
+	info.Title = pdfInfoString(body, "Title")
+	info.Author = pdfInfoString(body, "Author")
+	return info, nil
+}
+
+// pdfInfoString returns the value of a document information entry such as /Title, given as a
+// literal or hexadecimal string.
+func pdfInfoString(body []byte, key string) string {
+	i := bytes.Index(body, []byte("/"+key))
+	if i < 0 {
+		return ""
+	}
+	rest := bytes.TrimLeft(body[i+len(key)+1:], " \t\r\n")
+	if len(rest) == 0 {
+		return ""
+	}
+
+	var raw []byte
+	switch rest[0] {
+	case '(':
+		raw = pdfLiteralString(rest[1:])
+	case '<':
+		end := bytes.IndexByte(rest, '>')
+		if end < 0 {
+			return ""
+		}
+		decoded, err := hex.DecodeString(string(bytes.Join(bytes.Fields(rest[1:end]), nil)))
+		if err != nil {
+			return ""
+		}
+		raw = decoded
+	default:
+		return ""
+	}
+	return strings.TrimSpace(pdfTextString(raw))
+}
+
+// pdfLiteralString unescapes a PDF literal string, starting after its opening parenthesis.
+func pdfLiteralString(s []byte) []byte {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				end := i
+				for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+					end++
+				}
+				n, _ := strconv.ParseUint(string(s[i:end]), 8, 8)
+				out = append(out, byte(n))
+				i = end - 1
+			case '\r', '\n':
+				// Line continuation
+			default:
+				out = append(out, s[i])
+			}
+		case c == '(':
+			depth++
+			out = append(out, c)
+		case c == ')':
+			if depth == 0 {
+				return out
+			}
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// pdfTextString decodes a PDF text string, which is UTF-16BE when it starts with a byte order
+// mark and PDFDocEncoding (approximated as Latin-1) otherwise.
+func pdfTextString(raw []byte) string {
+	if bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		raw = raw[2:]
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 0; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	if utf8.Valid(raw) {
+		return string(raw)
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// analyzeImage is the built-in "image" analyzer. It reads the dimensions of GIF, JPEG and PNG
+// images; other formats only report their format.
+func analyzeImage(page *Page) (any, error) {
+	info := models.ImageInfo{Format: strings.TrimPrefix(page.MediaType, "image/")}
+	if config, format, err := image.DecodeConfig(bytes.NewReader(page.Body)); err == nil {
+		info.Format = format
+		info.Width = config.Width
+		info.Height = config.Height
+	}
+	return info, nil
+}
+
+// analyzeText is the built-in "text" analyzer for plain text, CSV, Markdown and JSON documents.
+func analyzeText(page *Page) (any, error) {
+	text := string(page.Body)
+	info := models.TextInfo{
+		Words:      len(strings.Fields(text)),
+		Characters: utf8.RuneCountInString(text),
+	}
+	if text != "" {
+		info.Lines = strings.Count(text, "\n") + 1
+		if strings.HasSuffix(text, "\n") {
+			info.Lines--
+		}
+	}
+	if strings.HasSuffix(page.MediaType, "json") {
+		valid := json.Valid(page.Body)
+		info.ValidJSON = &valid
+	}
+	return info, nil
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+const samplePDF = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R >> endobj
+4 0 obj << /Type /Page /Parent 2 0 R >> endobj
+5 0 obj << /Title (Annual \(2024\) Report) /Author <FEFF004A006F> >> endobj
+trailer << /Root 1 0 R /Info 5 0 R >>
+%%EOF`
+
+func TestCrawl_NonHTMLContent(t *testing.T) {
+	var pngImage bytes.Buffer
+	require.NoError(t, png.Encode(&pngImage, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte(samplePDF))
+		case "/logo":
+			// No Content-Type; the body is sniffed
+			w.Header()["Content-Type"] = nil
+			_, _ = w.Write(pngImage.Bytes())
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"broken": `))
+		case "/notes.txt":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte("first line\nsecond line\n"))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		path     string
+		analyzer string
+		section  string
+	}{
+		{"/report.pdf", "pdf", `{"version": "1.4", "pageCount": 2, "title": "Annual (2024) Report", "author": "Jo", "encrypted": false}`},
+		{"/logo", "image", `{"format": "png", "width": 3, "height": 2}`},
+		{"/data.json", "text", `{"lines": 1, "words": 1, "characters": 11, "validJson": false}`},
+		{"/notes.txt", "text", `{"lines": 2, "words": 4, "characters": 23}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := &models.CrawlResult{URL: ts.URL + tt.path, Headings: make(map[string]int)}
+
+			require.NoError(t, Crawl(result))
+			assert.Equal(t, "completed", result.Status)
+			assert.Equal(t, http.StatusOK, result.HTTPStatusCode)
+			assert.Empty(t, result.HTMLVersion)
+			assert.Len(t, result.Sections, 1)
+			assert.JSONEq(t, tt.section, string(result.Sections[tt.analyzer].Data))
+		})
+	}
+}
+
+func TestCrawl_RecordsResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte("<!DOCTYPE html><title>Page</title>"))
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, Crawl(result))
+	assert.Equal(t, http.StatusOK, result.HTTPStatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", result.ContentType)
+	assert.Equal(t, int64(34), result.ContentSize)
+
+	result = &models.CrawlResult{URL: ts.URL + "/missing", Headings: make(map[string]int)}
+	err := Crawl(result)
+	assert.EqualError(t, err, "HTTP status 404 Not Found")
+	assert.Equal(t, "error", result.Status)
+	assert.Equal(t, "HTTP status 404 Not Found", result.ErrorMessage)
+	assert.Equal(t, http.StatusNotFound, result.HTTPStatusCode)
+	assert.Empty(t, result.PageTitle)
+}
+
+func TestAnalyzePDF_UTF16Title(t *testing.T) {
+	page := &Page{Body: []byte("%PDF-1.7\n<< /Title (\\376\\377\\000C\\000a\\000f\\000\\351) >>\n<< /Type /Page >>")}
+
+	info, err := analyzePDF(page)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.PDFInfo{Version: "1.7", PageCount: 1, Title: "Café"}, info)
+}
@@ -1,11 +1,13 @@
 package crawler
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/url"
+	"net/http/httptrace"
 	"strings"
 	"sync"
 	"time"
@@ -15,9 +17,18 @@ import (
 )
 
 // Crawl performs the crawling of a single URL.
-func Crawl(result *models.CrawlResult) error {
+func (c *Crawler) Crawl(result *models.CrawlResult) error {
 	// Fetch the URL
-	resp, err := http.Get(result.URL)
+	req, err := http.NewRequest(http.MethodGet, result.URL, nil)
+	if err != nil {
+		result.Status = "error"
+		result.ErrorMessage = err.Error()
+		return err
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	timer := newFetchTimer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timer.trace()))
+	resp, err := c.client.Do(req)
 	if err != nil {
 		result.Status = "error"
 		result.ErrorMessage = err.Error()
@@ -25,30 +36,119 @@ func Crawl(result *models.CrawlResult) error {
 	}
 	defer resp.Body.Close()
 
-	// Read the response body into a buffer so it can be read multiple times
-	bodyBytes, err := io.ReadAll(resp.Body)
+	result.HTTPStatusCode = resp.StatusCode
+	result.ContentType = resp.Header.Get("Content-Type")
+
+	// Stream the response body, decompressed and up to the size limit, peeking at its start to
+	// tell its media type
+	body, err := openBody(resp, c.config.MaxBodySize)
 	if err != nil {
 		result.Status = "error"
 		result.ErrorMessage = err.Error()
 		return err
 	}
+	defer body.Close()
+	reader := bufio.NewReaderSize(body, sniffLength)
+	prefix, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		result.Status = "error"
+		result.ErrorMessage = err.Error()
+		return err
+	}
+	prefix = bytes.Clone(prefix)
+	mediaType := mediaTypeOf(result.ContentType, prefix)
 
-	// Get the HTML version
-	result.HTMLVersion = getHTMLVersion(bodyBytes)
-
-	// Parse the HTML
-	doc, err := html.Parse(bytes.NewReader(bodyBytes))
+	// Error pages are discarded and content other than HTML is buffered for the analyzers that
+	// accept it; HTML is parsed as it streams in, transcoded to UTF-8 on the fly
+	var bodyBytes []byte
+	var doc *html.Node
+	switch {
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		_, err = io.Copy(io.Discard, reader)
+	case !isHTMLMediaType(mediaType):
+		bodyBytes, err = io.ReadAll(reader)
+	default:
+		doc, err = html.Parse(decodeBody(reader, result.ContentType, result))
+	}
 	if err != nil {
 		result.Status = "error"
 		result.ErrorMessage = err.Error()
 		return err
 	}
+	result.ContentSize = body.size
+	result.BodyTruncated = body.truncated
+
+	// Record the timings and sizes of the fetch
+	result.Performance = models.Performance{
+		Protocol:     resp.Proto,
+		Compression:  compressionOf(resp),
+		TransferSize: body.transferSize(),
+		DecodedSize:  result.ContentSize,
+		PageWeight:   result.ContentSize,
+	}
+	timer.record(&result.Performance, body.doneAt)
+
+	// Inspect the TLS connection and certificate of HTTPS pages
+	if resp.TLS != nil {
+		result.TLS = inspectTLS(resp.TLS, resp.Request.URL.Hostname(), c.roots, c.config.CertExpiryWarningDays, time.Now())
+	}
+
+	// Error pages are not analyzed
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("HTTP status %s", resp.Status)
+		result.Status = "error"
+		result.ErrorMessage = err.Error()
+		return err
+	}
+
+	// Content other than HTML is only handed to the analyzers that accept it
+	page := &Page{URL: resp.Request.URL, BaseURL: resp.Request.URL, Response: resp, MediaType: mediaType, Body: bodyBytes, Result: result}
+	if !isHTMLMediaType(page.MediaType) {
+		c.registry.Run(page, result.Options.Analyzers)
+		result.Status = "completed"
+		result.UpdatedAt = time.Now()
+		return nil
+	}
+
+	// Get the HTML version and rendering mode from the doctype
+	result.HTMLVersion, result.RenderingMode = getHTMLVersion(doc, prefix, result.ContentType)
 
 	// Extract information from the parsed HTML
-	links := extractInfo(doc, result)
+	// Resolve relative URLs against the <base href> of the page, if it has one
+	base, baseFindings := effectiveBaseURL(doc, resp.Request.URL)
+	result.BaseURL = base.String()
+	result.LinkFindings = baseFindings
+	links := classifyLinks(extractInfo(doc, result), base, resp.Request.URL, result)
+	result.HeadingIssues = validateOutline(result.Outline)
 
-	// Check the status of the links concurrently
-	checkLinks(links, result)
+	// Inventory the resources of the page, probing their sizes if requested
+	resources := collectResources(doc, base)
+	inventoryResources(resources, &result.Performance)
+	if result.Options.ProbeResources {
+		c.probeResourceSizes(&result.Performance)
+	}
+
+	// Inventory the forms and detect whether the page offers a login
+	result.Forms = inventoryForms(doc, base)
+	result.HasLoginForm = hasLogin(result.Forms)
+
+	// Run the analyzers enabled for this job
+	page.Doc = doc
+	page.BaseURL = base
+	page.resources = resources
+	c.registry.Run(page, result.Options.Analyzers)
+
+	// Check the status of the links and resources concurrently, reusing recently checked statuses
+	result.LinkCacheStats = models.LinkCacheStats{Bypassed: result.Options.BypassLinkCache}
+	c.checkLinks(links, result)
+	c.checkResources(resources, result)
+	if c.linkCache != nil {
+		stats := c.linkCache.Stats()
+		log.Printf("Link cache: %d hits, %d misses, %d entries\n", stats.Hits, stats.Misses, stats.Entries)
+	}
+
+	// Check that the fragments of internal links point at an element
+	c.checkAnchors(links, resp.Request.URL, doc, result)
 
 	// Set the status to completed
 	result.Status = "completed"
@@ -58,6 +158,7 @@ func Crawl(result *models.CrawlResult) error {
 }
 
 // extractInfo traverses the HTML document and extracts the required information.
+// It returns the href of every link, unresolved, for classifyLinks.
 func extractInfo(n *html.Node, result *models.CrawlResult) []string {
 	var links []string
 	if n.Type == html.ElementNode {
@@ -68,30 +169,23 @@ func extractInfo(n *html.Node, result *models.CrawlResult) []string {
 			}
 		case "h1", "h2", "h3", "h4", "h5", "h6":
 			result.Headings[n.Data]++
+			result.Outline = append(result.Outline, models.Heading{
+				Level:    int(n.Data[1] - '0'),
+				Text:     headingText(n),
+				Position: len(result.Outline),
+			})
+		case "meta":
+			// The description is part of the page's core information, searched whether or not
+			// the seo analyzer runs
+			if strings.EqualFold(strings.TrimSpace(getAttr(n, "name")), "description") && result.MetaDescription == "" {
+				result.MetaDescription = strings.TrimSpace(getAttr(n, "content"))
+			}
 		case "a":
 			for _, attr := range n.Attr {
 				if attr.Key == "href" {
-					link, err := url.Parse(attr.Val)
-					if err != nil {
-						continue
-					}
-					baseURL, err := url.Parse(result.URL)
-					if err != nil {
-						log.Printf("Error parsing base URL %s: %v", result.URL, err)
-						return links
-					}
-					resolvedLink := baseURL.ResolveReference(link)
-					links = append(links, resolvedLink.String())
-
-					if link.Host == "" || link.Host == baseURL.Host {
-						result.InternalLinksCount++
-					} else {
-						result.ExternalLinksCount++
-					}
+					links = append(links, attr.Val)
 				}
 			}
-		case "form":
-			checkForLoginForm(n, result)
 		}
 	}
 
@@ -101,78 +195,120 @@ func extractInfo(n *html.Node, result *models.CrawlResult) []string {
 	return links
 }
 
-// checkForLoginForm checks if a form contains a password input field.
-func checkForLoginForm(n *html.Node, result *models.CrawlResult) {
-	if n.Type == html.ElementNode && n.Data == "input" {
-		for _, attr := range n.Attr {
-			if attr.Key == "type" && attr.Val == "password" {
-				result.HasLoginForm = true
-				return
-			}
+// textContent returns the text of a node and its descendants with whitespace collapsed.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
 		}
 	}
+	collect(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
 
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		checkForLoginForm(c, result)
+// getAttr returns the value of the named attribute, or an empty string if it is not set.
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
 	}
+	return ""
 }
 
-// getHTMLVersion tries to determine the HTML version from the doctype.
-func getHTMLVersion(bodyBytes []byte) string {
-	// Read the entire body as a string for doctype detection
-	bodyString := strings.ToLower(strings.TrimSpace(string(bodyBytes)))
-
-	signatures := []struct {
-		Keyword string
-		Version string
-	}{
-		{"<!doctype html>", "HTML5"},
-		{"-//w3c//dtd html 4.01//en", "HTML 4.01 Strict"},
-		{"-//w3c//dtd xhtml 1.0 strict//en", "XHTML 1.0 Strict"},
-		{"-//ietf//dtd html 2.0//en", "HTML 2.0"},
-		{"-//w3c//dtd html 3.2 final//en", "HTML 3.2"},
-		{"-//w3c//dtd xhtml 1.1//en", "XHTML 1.1"},
-		{"html profile=", "HTML5 with profile"},
-	}
-
-	for _, sig := range signatures {
-		if strings.Contains(bodyString, sig.Keyword) {
-			return sig.Version
+// checkLinks checks the status of a list of links concurrently.
+func (c *Crawler) checkLinks(links []string, result *models.CrawlResult) {
+	log.Printf("Total links to check: %d\n", len(links))
+	statuses := c.brokenURLs(links, result)
+	for _, link := range links {
+		if status, ok := statuses[link]; ok {
+			result.BrokenLinks = append(result.BrokenLinks, map[string]any{"url": link, "statusCode": status})
 		}
 	}
+	log.Printf("Found %d broken links.\n", len(result.BrokenLinks))
+	result.InaccessibleLinksCount = len(result.BrokenLinks)
+}
 
-	return "Unknown"
+// checkResources checks the status of the resources of a page concurrently: images, scripts,
+// stylesheets, fonts, media, frames, and canonical and meta refresh targets. Form actions
+// are not checked, as they expect a submission. Broken resources are recorded separately
+// from broken links.
+func (c *Crawler) checkResources(resources []resource, result *models.CrawlResult) {
+	var urls []string
+	kinds := map[string]string{}
+	for _, r := range resources {
+		if _, seen := kinds[r.URL]; seen || r.Kind == resourceForm {
+			continue
+		}
+		kinds[r.URL] = r.Kind
+		urls = append(urls, r.URL)
+	}
+
+	log.Printf("Total resources to check: %d\n", len(urls))
+	result.BrokenResources = make([]map[string]any, 0)
+	statuses := c.brokenURLs(urls, result)
+	for _, u := range urls {
+		if status, ok := statuses[u]; ok {
+			result.BrokenResources = append(result.BrokenResources, map[string]any{"url": u, "kind": kinds[u], "statusCode": status})
+		}
+	}
+	log.Printf("Found %d broken resources.\n", len(result.BrokenResources))
+	result.InaccessibleResourcesCount = len(result.BrokenResources)
 }
 
-// checkLinks checks the status of a list of links concurrently.
-func checkLinks(links []string, result *models.CrawlResult) {
+// brokenURLs checks the status of each URL concurrently and returns the status codes of those
+// answering with a 4xx or 5xx status. Statuses are taken from the link cache unless the job
+// bypasses it; the hits and misses are counted on the result.
+func (c *Crawler) brokenURLs(urls []string, result *models.CrawlResult) map[string]int {
 	var wg sync.WaitGroup
-	brokenLinksChan := make(chan map[string]any, len(links))
+	var mu sync.Mutex
+	broken := map[string]int{}
+	bypass := result.Options.BypassLinkCache
 
-	log.Printf("Total links to check: %d\n", len(links))
-	for _, link := range links {
+	for _, u := range urls {
 		wg.Add(1)
-		go func(link string) {
+		go func(u string) {
 			defer wg.Done()
-			log.Printf("Checking link: %s\n", link)
-			resp, err := http.Head(link)
-			if err != nil {
-				log.Printf("Error checking link %s: %v\n", link, err)
-				return
+			var status int
+			var hit bool
+			if c.linkCache != nil {
+				status, hit = c.linkCache.Status(u, bypass, c.headStatus)
+			} else {
+				status = c.headStatus(u)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if hit {
+				result.LinkCacheStats.Hits++
+			} else {
+				result.LinkCacheStats.Misses++
 			}
-			log.Printf("Link %s returned status: %d\n", link, resp.StatusCode)
-			if resp.StatusCode >= 400 {
-				brokenLinksChan <- map[string]any{"url": link, "statusCode": resp.StatusCode}
+			if status >= 400 {
+				broken[u] = status
 			}
-		}(link)
+		}(u)
 	}
 
 	wg.Wait()
-	close(brokenLinksChan)
+	return broken
+}
 
-	for brokenLink := range brokenLinksChan {
-		result.BrokenLinks = append(result.BrokenLinks, brokenLink)
+// headStatus sends a HEAD request to a URL and returns the status code, or 0 if the URL
+// cannot be reached.
+func (c *Crawler) headStatus(u string) int {
+	log.Printf("Checking link: %s\n", u)
+	resp, err := c.linkClient.Head(u)
+	if err != nil {
+		log.Printf("Error checking link %s: %v\n", u, err)
+		return 0
 	}
-	log.Printf("Found %d broken links.\n", len(result.BrokenLinks))
-	result.InaccessibleLinksCount = len(result.BrokenLinks)
+	resp.Body.Close()
+	log.Printf("Link %s returned status: %d\n", u, resp.StatusCode)
+	return resp.StatusCode
 }
@@ -4,15 +4,38 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/krzysu/website-analyzer/internal/models"
+	"github.com/krzysu/website-analyzer/internal/netpolicy"
 )
 
+// loopbackAllowlist allows the test servers, which listen on the loopback address that the
+// network policy blocks by default.
+var loopbackAllowlist = []string{"127.0.0.0/8", "::1"}
+
+func TestMain(m *testing.M) {
+	os.Setenv("NETWORK_ALLOWLIST", strings.Join(loopbackAllowlist, ","))
+	os.Exit(m.Run())
+}
+
+// newTestCrawler creates a Crawler allowed to connect to the loopback test servers.
+func newTestCrawler(config Config) *Crawler {
+	policy, err := netpolicy.New(loopbackAllowlist, nil)
+	if err != nil {
+		panic(err)
+	}
+	config.NetworkPolicy = policy
+	return New(config)
+}
+
 func TestCrawl_BasicExtraction(t *testing.T) {
 	// Create a mock HTTP server
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -20,9 +43,9 @@ func TestCrawl_BasicExtraction(t *testing.T) {
 		// Simulate HTML5 doctype
 		_, err := w.Write([]byte(`<!DOCTYPE html>
 <html>
-<head><title>Test Page</title></head>
+<head><title>Test Page</title><meta name="description" content=" A page for testing. "></head>
 <body>
-<h1>Heading 1</h1>
+<h1>Heading <em>1</em></h1>
 <h2>Heading 2</h2>
 <a href="/internal">Internal Link</a>
 <a href="http://external.com">External Link</a>
@@ -47,8 +70,12 @@ func TestCrawl_BasicExtraction(t *testing.T) {
 
 	assert.Equal(t, "Test Page", result.PageTitle)
 	assert.Equal(t, "HTML5", result.HTMLVersion)
+	assert.Equal(t, "standards", result.RenderingMode)
 	assert.Equal(t, 1, result.Headings["h1"])
 	assert.Equal(t, 1, result.Headings["h2"])
+	assert.Equal(t, models.HeadingList{{Level: 1, Text: "Heading 1", Position: 0}, {Level: 2, Text: "Heading 2", Position: 1}}, result.Outline)
+	assert.False(t, result.HeadingIssues.MissingH1)
+	assert.Equal(t, "A page for testing.", result.MetaDescription)
 	assert.Equal(t, 1, result.InternalLinksCount)
 	assert.Equal(t, 1, result.ExternalLinksCount)
 	assert.True(t, result.HasLoginForm)
@@ -139,6 +166,51 @@ func TestCrawl_InaccessibleLinks(t *testing.T) {
 	assert.True(t, found500)
 }
 
+func TestCrawl_BrokenResources(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html>
+<html>
+<head>
+<link rel="stylesheet" href="/style.css">
+<link rel="icon" href="/missing-icon.png">
+<script src="/missing-bundle.js"></script>
+</head>
+<body>
+<img src="/hero.jpg" srcset="/hero.jpg 1x, /missing-hero-2x.jpg 2x">
+<iframe src="/broken-frame"></iframe>
+<video><source src="/clip.mp4"></video>
+<form action="/missing-form-handler"></form>
+<a href="/about">About</a>
+</body>
+</html>`))
+			assert.NoError(t, err)
+		case "/style.css", "/hero.jpg", "/clip.mp4", "/about":
+			w.WriteHeader(http.StatusOK)
+		case "/broken-frame":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, Crawl(result))
+
+	// Broken resources are reported in document order, separately from the links
+	assert.Equal(t, 0, result.InaccessibleLinksCount)
+	assert.Equal(t, 4, result.InaccessibleResourcesCount)
+	assert.Equal(t, models.JSONArray{
+		{"url": ts.URL + "/missing-icon.png", "kind": "image", "statusCode": 404},
+		{"url": ts.URL + "/missing-bundle.js", "kind": "script", "statusCode": 404},
+		{"url": ts.URL + "/missing-hero-2x.jpg", "kind": "image", "statusCode": 404},
+		{"url": ts.URL + "/broken-frame", "kind": "iframe", "statusCode": 500},
+	}, result.BrokenResources)
+}
+
 func TestCrawl_ErrorHandling(t *testing.T) {
 	// Test with an invalid URL (unsupported protocol scheme)
 	result := &models.CrawlResult{
@@ -211,79 +283,41 @@ func TestCrawl_ErrorHandling(t *testing.T) {
 	assert.Equal(t, 1, result.InaccessibleLinksCount)
 }
 
-func TestGetHTMLVersion(t *testing.T) {
-	tests := []struct {
-		name     string
-		html     string
-		expected string
-	}{
-		{
-			name:     "HTML5 Doctype",
-			html:     "<!DOCTYPE html>\n<html><body></body></html>",
-			expected: "HTML5",
-		},
-		{
-			name:     "HTML5 Doctype with leading spaces",
-			html:     "  <!DOCTYPE html>\n<html><body></body></html>",
-			expected: "HTML5",
-		},
-		{
-			name:     "HTML 4.01 Strict Doctype",
-			html:     "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01//EN\" \"http://www.w3.org/TR/html4/strict.dtd\">\n<html><body></body></html>",
-			expected: "HTML 4.01 Strict",
-		},
-		{
-			name:     "XHTML 1.0 Strict Doctype",
-			html:     "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd\">\n<html><body></body></html>",
-			expected: "XHTML 1.0 Strict",
-		},
-		{
-			name:     "No Doctype",
-			html:     "<html><body></body></html>",
-			expected: "Unknown",
-		},
-		{
-			name:     "Empty HTML",
-			html:     "",
-			expected: "Unknown",
-		},
-		{
-			name:     "Doctype on second line",
-			html:     "\n<!DOCTYPE html>\n<html><body></body></html>",
-			expected: "HTML5",
-		},
-		{
-			name:     "HTML 2.0 Doctype",
-			html:     "<!DOCTYPE html PUBLIC \"-//IETF//DTD HTML 2.0//EN\">\n<html><body></body></html>",
-			expected: "HTML 2.0",
-		},
-		{
-			name:     "HTML 3.2 Doctype",
-			html:     "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 3.2 Final//EN\">\n<html><body></body></html>",
-			expected: "HTML 3.2",
-		},
-		{
-			name:     "XHTML 1.1 Doctype",
-			html:     "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.1//EN\" \"http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd\">\n<html><body></body></html>",
-			expected: "XHTML 1.1",
-		},
-		{
-			name:     "HTML5 with profile",
-			html:     "<!DOCTYPE html profile=\"http://www.w3.org/2000/svg\">\n<html><body></body></html>",
-			expected: "HTML5 with profile",
-		},
-		{
-			name:     "HTML5 Doctype and html tag on same line",
-			html:     "<!DOCTYPE html><html lang=\"en\"><head><meta charSet=\"utf-8\"/>",
-			expected: "HTML5",
-		},
-	}
+func TestCrawl_NetworkPolicy(t *testing.T) {
+	var internalHits int
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits++
+	}))
+	defer internal.Close()
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create a dummy http.Response
-			version := getHTMLVersion([]byte(tt.html))
-			assert.Equal(t, tt.expected, version)
-		})
-	}
+	// The page is served on 127.0.0.1 and allowed; its link to the "internal" server on localhost is not
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		internalURL := strings.Replace(internal.URL, "127.0.0.1", "localhost", 1)
+		fmt.Fprintf(w, `<!DOCTYPE html><html><body><a href="%s/admin">Admin</a></body></html>`, internalURL)
+	}))
+	defer page.Close()
+
+	policy, err := netpolicy.New([]string{"127.0.0.1"}, []string{"localhost"})
+	require.NoError(t, err)
+	c := New(Config{NetworkPolicy: policy, LinkCacheTTL: -1})
+
+	result := &models.CrawlResult{URL: page.URL, Headings: make(map[string]int)}
+	require.NoError(t, c.Crawl(result))
+	assert.Equal(t, 0, internalHits)
+
+	// Blocked pages fail the crawl; without a policy, the default one applies
+	c = New(Config{})
+	result = &models.CrawlResult{URL: page.URL, Headings: make(map[string]int)}
+	err = c.Crawl(result)
+	assert.ErrorIs(t, err, netpolicy.ErrBlocked)
+	assert.Equal(t, "error", result.Status)
+	assert.Contains(t, result.ErrorMessage, "blocked by network policy: loopback address")
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u
 }
@@ -0,0 +1,228 @@
+package crawler
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rendering modes a browser picks for a document based on its doctype.
+const (
+	renderingStandards       = "standards"
+	renderingAlmostStandards = "almost-standards"
+	renderingQuirks          = "quirks"
+)
+
+// doctypeVersions maps lowercased public identifier prefixes, without the language suffix, to HTML versions.
+var doctypeVersions = []struct {
+	publicID string
+	version  string
+}{
+	{"-//ietf//dtd html 2.0//", "HTML 2.0"},
+	{"-//ietf//dtd html//", "HTML 2.0"},
+	{"-//w3c//dtd html 3.2 final//", "HTML 3.2"},
+	{"-//w3c//dtd html 3.2//", "HTML 3.2"},
+	{"-//w3c//dtd html 4.0//", "HTML 4.0 Strict"},
+	{"-//w3c//dtd html 4.0 transitional//", "HTML 4.0 Transitional"},
+	{"-//w3c//dtd html 4.0 frameset//", "HTML 4.0 Frameset"},
+	{"-//w3c//dtd html 4.01//", "HTML 4.01 Strict"},
+	{"-//w3c//dtd html 4.01 transitional//", "HTML 4.01 Transitional"},
+	{"-//w3c//dtd html 4.01 frameset//", "HTML 4.01 Frameset"},
+	{"-//w3c//dtd xhtml 1.0 strict//", "XHTML 1.0 Strict"},
+	{"-//w3c//dtd xhtml 1.0 transitional//", "XHTML 1.0 Transitional"},
+	{"-//w3c//dtd xhtml 1.0 frameset//", "XHTML 1.0 Frameset"},
+	{"-//w3c//dtd xhtml 1.1//", "XHTML 1.1"},
+	{"-//w3c//dtd xhtml basic 1.0//", "XHTML Basic 1.0"},
+	{"-//w3c//dtd xhtml basic 1.1//", "XHTML Basic 1.1"},
+	{"-//wapforum//dtd xhtml mobile 1.0//", "XHTML Mobile 1.0"},
+	{"-//wapforum//dtd xhtml mobile 1.1//", "XHTML Mobile 1.1"},
+	{"-//wapforum//dtd xhtml mobile 1.2//", "XHTML Mobile 1.2"},
+	{"-//w3c//dtd xhtml+rdfa 1.0//", "XHTML+RDFa 1.0"},
+	{"-//w3c//dtd xhtml+rdfa 1.1//", "XHTML+RDFa 1.1"},
+}
+
+// quirkyPublicIDPrefixes are lowercased public identifier prefixes that put browsers in quirks
+// mode, per the HTML specification's "initial" insertion mode.
+var quirkyPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// quirkyPublicIDs are lowercased public identifiers that put browsers in quirks mode when matched exactly.
+var quirkyPublicIDs = map[string]bool{
+	"-//w3o//dtd w3 html strict 3.0//en//": true,
+	"-/w3c/dtd html 4.0 transitional/en":   true,
+	"html":                                 true,
+}
+
+// getHTMLVersion determines the HTML version and rendering mode of a document from its doctype.
+// body is the start of the raw markup, used to tell a malformed doctype apart from a plain "<!DOCTYPE html>".
+// Documents served as XHTML are parsed as XML by browsers, which always render in standards mode.
+func getHTMLVersion(doc *html.Node, body []byte, contentType string) (version, renderingMode string) {
+	xhtml := isXHTMLContentType(contentType)
+
+	doctype := findDoctype(doc)
+	if doctype == nil {
+		if xhtml {
+			return "XHTML", renderingStandards
+		}
+		return "Unknown", renderingQuirks
+	}
+
+	name := strings.ToLower(doctype.Data)
+	publicID := strings.TrimSpace(getAttr(doctype, "public"))
+	systemID := strings.TrimSpace(getAttr(doctype, "system"))
+
+	version = "Unknown"
+	switch {
+	case name != "html":
+	case publicID == "" && (systemID == "" || strings.EqualFold(systemID, "about:legacy-compat")):
+		version = "HTML5"
+		if xhtml {
+			version = "XHTML5"
+		}
+	default:
+		lowerPublicID := strings.ToLower(publicID)
+		for _, dt := range doctypeVersions {
+			if strings.HasPrefix(lowerPublicID, dt.publicID) {
+				version = dt.version
+				break
+			}
+		}
+	}
+
+	if xhtml {
+		return version, renderingStandards
+	}
+	forceQuirks := len(doctype.Attr) == 0 && hasTrailingDoctypeContent(body)
+	return version, renderingModeFor(name, publicID, systemID, forceQuirks)
+}
+
+// findDoctype returns the doctype node of a parsed document, or nil if it has none.
+func findDoctype(doc *html.Node) *html.Node {
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.DoctypeNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// hasTrailingDoctypeContent reports whether the leading doctype of body has content after
+// its name, e.g. `<!DOCTYPE html profile="...">`. Without public or system identifiers such
+// content is malformed and the HTML tokenizer sets the force-quirks flag.
+func hasTrailingDoctypeContent(body []byte) bool {
+	_, body = stripBOM(body)
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.CommentToken:
+			continue
+		case html.TextToken:
+			if len(bytes.TrimSpace(z.Text())) == 0 {
+				continue
+			}
+			return false
+		case html.DoctypeToken:
+			raw := strings.TrimSpace(string(z.Text()))
+			return strings.IndexAny(raw, " \t\n\f\r") != -1
+		default:
+			return false
+		}
+	}
+}
+
+// renderingModeFor applies the HTML specification's doctype rules for quirks and limited-quirks
+// (almost standards) mode.
+func renderingModeFor(name, publicID, systemID string, forceQuirks bool) string {
+	publicID = strings.ToLower(publicID)
+	systemID = strings.ToLower(systemID)
+
+	hasPrefix := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(publicID, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case forceQuirks || name != "html" || quirkyPublicIDs[publicID]:
+		return renderingQuirks
+	case systemID == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd":
+		return renderingQuirks
+	case hasPrefix(quirkyPublicIDPrefixes...):
+		return renderingQuirks
+	case hasPrefix("-//w3c//dtd html 4.01 frameset//", "-//w3c//dtd html 4.01 transitional//"):
+		if systemID == "" {
+			return renderingQuirks
+		}
+		return renderingAlmostStandards
+	case hasPrefix("-//w3c//dtd xhtml 1.0 frameset//", "-//w3c//dtd xhtml 1.0 transitional//"):
+		return renderingAlmostStandards
+	default:
+		return renderingStandards
+	}
+}
+
+// isXHTMLContentType reports whether a Content-Type header denotes XHTML.
+func isXHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/xhtml+xml"
+}
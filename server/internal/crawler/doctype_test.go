@@ -0,0 +1,179 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestGetHTMLVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		html          string
+		contentType   string
+		expected      string
+		renderingMode string
+	}{
+		{
+			name:          "HTML5 Doctype",
+			html:          "<!DOCTYPE html>\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML5 Doctype with leading spaces",
+			html:          "  <!DOCTYPE html>\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML5 legacy-compat Doctype",
+			html:          "<!DOCTYPE html SYSTEM \"about:legacy-compat\">\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML 4.01 Strict Doctype",
+			html:          "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01//EN\" \"http://www.w3.org/TR/html4/strict.dtd\">\n<html><body></body></html>",
+			expected:      "HTML 4.01 Strict",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML 4.01 Transitional Doctype",
+			html:          "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\" \"http://www.w3.org/TR/html4/loose.dtd\">\n<html><body></body></html>",
+			expected:      "HTML 4.01 Transitional",
+			renderingMode: "almost-standards",
+		},
+		{
+			name:          "HTML 4.01 Transitional Doctype without system identifier",
+			html:          "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\">\n<html><body></body></html>",
+			expected:      "HTML 4.01 Transitional",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "HTML 4.01 Frameset Doctype",
+			html:          "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Frameset//EN\" \"http://www.w3.org/TR/html4/frameset.dtd\">\n<html><body></body></html>",
+			expected:      "HTML 4.01 Frameset",
+			renderingMode: "almost-standards",
+		},
+		{
+			name:          "XHTML 1.0 Strict Doctype",
+			html:          "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Strict//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd\">\n<html><body></body></html>",
+			expected:      "XHTML 1.0 Strict",
+			renderingMode: "standards",
+		},
+		{
+			name:          "XHTML 1.0 Transitional Doctype",
+			html:          "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\">\n<html><body></body></html>",
+			expected:      "XHTML 1.0 Transitional",
+			renderingMode: "almost-standards",
+		},
+		{
+			name:          "XHTML 1.0 Frameset Doctype",
+			html:          "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Frameset//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd\">\n<html><body></body></html>",
+			expected:      "XHTML 1.0 Frameset",
+			renderingMode: "almost-standards",
+		},
+		{
+			name:          "No Doctype",
+			html:          "<html><body></body></html>",
+			expected:      "Unknown",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "Empty HTML",
+			html:          "",
+			expected:      "Unknown",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "Doctype on second line",
+			html:          "\n<!DOCTYPE html>\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "Doctype after a comment",
+			html:          "<!-- generated -->\n<!DOCTYPE html>\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML 2.0 Doctype",
+			html:          "<!DOCTYPE html PUBLIC \"-//IETF//DTD HTML 2.0//EN\">\n<html><body></body></html>",
+			expected:      "HTML 2.0",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "HTML 3.2 Doctype",
+			html:          "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 3.2 Final//EN\">\n<html><body></body></html>",
+			expected:      "HTML 3.2",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "XHTML 1.1 Doctype",
+			html:          "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.1//EN\" \"http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd\">\n<html><body></body></html>",
+			expected:      "XHTML 1.1",
+			renderingMode: "standards",
+		},
+		{
+			name:          "Malformed HTML5 Doctype with profile",
+			html:          "<!DOCTYPE html profile=\"http://www.w3.org/2000/svg\">\n<html><body></body></html>",
+			expected:      "HTML5",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "HTML5 Doctype and html tag on same line",
+			html:          "<!DOCTYPE html><html lang=\"en\"><head><meta charSet=\"utf-8\"/>",
+			expected:      "HTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "Doctype mentioned in a code sample",
+			html:          "<html><body><pre>&lt;!DOCTYPE html&gt;</pre><p><!DOCTYPE html></p></body></html>",
+			expected:      "Unknown",
+			renderingMode: "quirks",
+		},
+		{
+			name:          "Unknown public identifier",
+			html:          "<!DOCTYPE html PUBLIC \"-//Example//DTD Custom//EN\">\n<html><body></body></html>",
+			expected:      "Unknown",
+			renderingMode: "standards",
+		},
+		{
+			name:          "HTML5 served as XHTML",
+			html:          "<!DOCTYPE html>\n<html xmlns=\"http://www.w3.org/1999/xhtml\"><body></body></html>",
+			contentType:   "application/xhtml+xml; charset=utf-8",
+			expected:      "XHTML5",
+			renderingMode: "standards",
+		},
+		{
+			name:          "XHTML without Doctype served as XHTML",
+			html:          "<html xmlns=\"http://www.w3.org/1999/xhtml\"><body></body></html>",
+			contentType:   "application/xhtml+xml",
+			expected:      "XHTML",
+			renderingMode: "standards",
+		},
+		{
+			name:          "XHTML 1.0 Transitional served as XHTML",
+			html:          "<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\">\n<html><body></body></html>",
+			contentType:   "application/xhtml+xml",
+			expected:      "XHTML 1.0 Transitional",
+			renderingMode: "standards",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.html))
+			require.NoError(t, err)
+
+			version, renderingMode := getHTMLVersion(doc, []byte(tt.html), tt.contentType)
+			assert.Equal(t, tt.expected, version)
+			assert.Equal(t, tt.renderingMode, renderingMode)
+		})
+	}
+}
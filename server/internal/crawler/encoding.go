@@ -0,0 +1,198 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+// metaPrescanLength is how far into a document browsers look for a <meta> charset declaration.
+const metaPrescanLength = 1024
+
+// sniffLength is how much of the start of a body is inspected to detect its media type and
+// encoding before the body is parsed as it streams in.
+const sniffLength = 64 << 10
+
+// fallbackEncoding is assumed for undeclared pages that are not valid UTF-8, as browsers do.
+const fallbackEncoding = "windows-1252"
+
+// byteOrderMarks maps byte order marks to the encoding they denote.
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// decodeBody returns a reader transcoding a page to UTF-8 as it is read from body. The encoding
+// comes from the byte order mark, the charset of the Content-Type header, or a <meta>
+// declaration, in that order, falling back to one detected from the first sniffLength bytes of
+// the content. The declared and detected encodings, and any disagreement between them, are
+// recorded on the result.
+func decodeBody(body *bufio.Reader, contentType string, result *models.CrawlResult) io.Reader {
+	result.EncodingFindings = models.FindingList{}
+	addFinding := func(code, severity, format string, args ...any) {
+		result.EncodingFindings = append(result.EncodingFindings, models.Finding{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Peek returns fewer bytes at the end of the body; a read error surfaces when parsing
+	prefix, _ := body.Peek(sniffLength)
+	bomEncoding, content := stripBOM(prefix)
+
+	var headerEncoding string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		headerEncoding = canonicalEncoding(params["charset"])
+		if headerEncoding == "" {
+			addFinding("encoding_unsupported", models.SeverityWarning, "Content-Type declares the unknown encoding %q", params["charset"])
+		}
+	}
+	var metaEncoding string
+	if label := metaCharset(content); label != "" {
+		metaEncoding = canonicalEncoding(label)
+		if metaEncoding == "" {
+			addFinding("encoding_unsupported", models.SeverityWarning, "<meta> declares the unknown encoding %q", label)
+		}
+	}
+
+	if headerEncoding != "" && metaEncoding != "" && headerEncoding != metaEncoding {
+		result.EncodingMismatch = true
+		addFinding("encoding_conflict", models.SeverityWarning, "Content-Type declares %s but <meta> declares %s", headerEncoding, metaEncoding)
+	}
+
+	result.DeclaredEncoding = headerEncoding
+	if result.DeclaredEncoding == "" {
+		result.DeclaredEncoding = metaEncoding
+	}
+	result.DetectedEncoding = bomEncoding
+	if result.DetectedEncoding == "" {
+		result.DetectedEncoding = sniffEncoding(content, result.DeclaredEncoding)
+	}
+
+	switch {
+	case result.DeclaredEncoding == "" && bomEncoding == "":
+		addFinding("encoding_undeclared", models.SeverityInfo, "No character encoding is declared; assumed %s", result.DetectedEncoding)
+	case result.DeclaredEncoding != "" && result.DetectedEncoding != result.DeclaredEncoding:
+		result.EncodingMismatch = true
+		addFinding("encoding_mismatch", models.SeverityError, "The page declares %s but its content is encoded as %s", result.DeclaredEncoding, result.DetectedEncoding)
+	}
+
+	used := bomEncoding
+	if used == "" {
+		used = result.DeclaredEncoding
+	}
+	if used == "" {
+		used = result.DetectedEncoding
+	}
+	body.Discard(len(prefix) - len(content)) // the byte order mark, already buffered
+	enc, _ := charset.Lookup(used)
+	if used == "utf-8" || enc == nil {
+		return body
+	}
+	return transform.NewReader(body, enc.NewDecoder())
+}
+
+// stripBOM returns the encoding denoted by a leading byte order mark, if any, and the content after it.
+func stripBOM(body []byte) (string, []byte) {
+	for _, b := range byteOrderMarks {
+		if bytes.HasPrefix(body, b.bom) {
+			return b.encoding, body[len(b.bom):]
+		}
+	}
+	return "", body
+}
+
+// canonicalEncoding returns the WHATWG name of an encoding label, or an empty string if the
+// label is unknown.
+func canonicalEncoding(label string) string {
+	_, name := charset.Lookup(strings.TrimSpace(label))
+	return name
+}
+
+// metaCharset returns the encoding label of the first <meta charset> or
+// <meta http-equiv="Content-Type"> declaration within the prescan window.
+func metaCharset(body []byte) string {
+	if len(body) > metaPrescanLength {
+		body = body[:metaPrescanLength]
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if token.Data != "meta" {
+				continue
+			}
+			var httpEquiv, content string
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "charset":
+					return attr.Val
+				case "http-equiv":
+					httpEquiv = attr.Val
+				case "content":
+					content = attr.Val
+				}
+			}
+			if strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+				if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+					return params["charset"]
+				}
+			}
+		}
+	}
+}
+
+// sniffEncoding guesses the encoding of content without a byte order mark. Content with
+// multi-byte UTF-8 sequences is UTF-8; content that is not valid UTF-8 is assumed to be in
+// the declared legacy encoding, or windows-1252. ASCII content is compatible with any
+// declaration. A multi-byte sequence cut off at the end of the sniffed prefix is ignored.
+func sniffEncoding(content []byte, declared string) string {
+	valid := utf8.Valid(trimPartialRune(content))
+	switch {
+	case valid && hasNonASCII(content):
+		return "utf-8"
+	case valid && declared != "":
+		return declared
+	case valid:
+		return "utf-8"
+	case declared != "" && declared != "utf-8":
+		return declared
+	default:
+		return fallbackEncoding
+	}
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of content.
+func trimPartialRune(content []byte) []byte {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(content); i++ {
+		if tail := content[len(content)-i:]; utf8.RuneStart(tail[0]) {
+			if !utf8.FullRune(tail) {
+				return content[:len(content)-i]
+			}
+			break
+		}
+	}
+	return content
+}
+
+func hasNonASCII(content []byte) bool {
+	for _, b := range content {
+		if b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		declared    string
+		detected    string
+		mismatch    bool
+		codes       []string
+		decoded     string
+	}{
+		{
+			name:        "UTF-8 declared in header",
+			body:        "<title>Café</title>",
+			contentType: "text/html; charset=UTF-8",
+			declared:    "utf-8",
+			detected:    "utf-8",
+			decoded:     "<title>Café</title>",
+		},
+		{
+			name:        "Windows-1252 declared in header",
+			body:        "<title>Caf\xe9</title>",
+			contentType: "text/html; charset=windows-1252",
+			declared:    "windows-1252",
+			detected:    "windows-1252",
+			decoded:     "<title>Café</title>",
+		},
+		{
+			name:     "ISO-8859-1 declared in meta",
+			body:     `<meta charset="iso-8859-1"><title>Caf` + "\xe9</title>",
+			declared: "windows-1252",
+			detected: "windows-1252",
+			decoded:  `<meta charset="iso-8859-1"><title>Café</title>`,
+		},
+		{
+			name:     "Shift_JIS declared in http-equiv meta",
+			body:     `<meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"><title>` + "\x93\xfa\x96\x7b</title>",
+			declared: "shift_jis",
+			detected: "shift_jis",
+			decoded:  `<meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS"><title>日本</title>`,
+		},
+		{
+			name:     "UTF-8 byte order mark",
+			body:     "\xef\xbb\xbf<title>Café</title>",
+			detected: "utf-8",
+			decoded:  "<title>Café</title>",
+		},
+		{
+			name:     "Undeclared legacy encoding",
+			body:     "<title>Caf\xe9</title>",
+			detected: "windows-1252",
+			codes:    []string{"encoding_undeclared"},
+			decoded:  "<title>Café</title>",
+		},
+		{
+			name:        "Declared legacy encoding but UTF-8 content",
+			body:        "<title>Café</title>",
+			contentType: "text/html; charset=iso-8859-1",
+			declared:    "windows-1252",
+			detected:    "utf-8",
+			mismatch:    true,
+			codes:       []string{"encoding_mismatch"},
+			decoded:     "<title>CafÃ©</title>",
+		},
+		{
+			name:        "Header and meta disagree",
+			body:        `<meta charset="utf-8"><title>Caf` + "\xe9</title>",
+			contentType: "text/html; charset=windows-1252",
+			declared:    "windows-1252",
+			detected:    "windows-1252",
+			mismatch:    true,
+			codes:       []string{"encoding_conflict"},
+			decoded:     `<meta charset="utf-8"><title>Café</title>`,
+		},
+		{
+			name:        "Unknown encoding label",
+			body:        "<title>Hello</title>",
+			contentType: "text/html; charset=klingon",
+			detected:    "utf-8",
+			codes:       []string{"encoding_unsupported", "encoding_undeclared"},
+			decoded:     "<title>Hello</title>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.CrawlResult{}
+			decoded, err := io.ReadAll(decodeBody(bufio.NewReader(strings.NewReader(tt.body)), tt.contentType, result))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.decoded, string(decoded))
+			assert.Equal(t, tt.declared, result.DeclaredEncoding)
+			assert.Equal(t, tt.detected, result.DetectedEncoding)
+			assert.Equal(t, tt.mismatch, result.EncodingMismatch)
+			if tt.codes == nil {
+				tt.codes = []string{}
+			}
+			assert.Equal(t, tt.codes, findingCodes(result.EncodingFindings))
+		})
+	}
+}
+
+func TestCrawl_TranscodesToUTF8(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+		_, err := w.Write([]byte("<!DOCTYPE html><html><head><title>Caf\xe9 \x93Cr\xe8me\x94</title></head><body></body></html>"))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int), CreatedAt: time.Now()}
+	require.NoError(t, Crawl(result))
+
+	assert.Equal(t, "Café “Crème”", result.PageTitle)
+	assert.Equal(t, "windows-1252", result.DeclaredEncoding)
+	assert.False(t, result.EncodingMismatch)
+}
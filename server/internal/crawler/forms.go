@@ -0,0 +1,305 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// maxSSOButtonLength is the longest link or button text considered a "Sign in with ..." button.
+const maxSSOButtonLength = 50
+
+var (
+	csrfFieldPattern     = regexp.MustCompile(`(?i)csrf|xsrf|^_token$|authenticity_token|requestverificationtoken|^nonce$`)
+	paymentFieldPattern  = regexp.MustCompile(`(?i)card.?num|cc.?num|cvv|cvc|card.?exp|security.?code`)
+	usernameFieldPattern = regexp.MustCompile(`(?i)user|login|email|account`)
+	searchFieldPattern   = regexp.MustCompile(`(?i)^(q|s|query|search|keywords?)$`)
+	loginKeywords        = regexp.MustCompile(`(?i)\b(log ?in|log-in|sign ?in|sign-in)\b`)
+	signupKeywords       = regexp.MustCompile(`(?i)\b(sign ?up|sign-up|register|registration|create (an )?account|join)\b`)
+	nextStepKeywords     = regexp.MustCompile(`(?i)^(next|continue)\b`)
+	searchKeywords       = regexp.MustCompile(`(?i)\bsearch\b`)
+	newsletterKeywords   = regexp.MustCompile(`(?i)\b(subscribe|newsletter|mailing list)\b`)
+	contactKeywords      = regexp.MustCompile(`(?i)\b(contact|message|enquiry|inquiry|get in touch)\b`)
+	ssoPattern           = regexp.MustCompile(`(?i)^(?:sign in|signin|log ?in|continue) (?:with|via|using) ([\p{L}\d.]+)`)
+)
+
+// buttonInputTypes are input types rendered as buttons rather than fields.
+var buttonInputTypes = map[string]bool{"submit": true, "button": true, "reset": true, "image": true}
+
+// textInputTypes are input types a user types a short value into.
+var textInputTypes = map[string]bool{"text": true, "email": true, "tel": true, "url": true, "search": true, "number": true}
+
+// inventoryForms lists the forms of a page and detects sign-in entry points. Relative form
+// actions are resolved against pageURL.
+func inventoryForms(doc *html.Node, pageURL *url.URL) models.FormInventory {
+	inventory := models.FormInventory{Forms: []models.Form{}, SSOProviders: []string{}}
+
+	addProvider := func(provider string) {
+		if provider != "" && !slices.Contains(inventory.SSOProviders, provider) {
+			inventory.SSOProviders = append(inventory.SSOProviders, provider)
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "form":
+				form, providers := inspectForm(n, pageURL)
+				inventory.Forms = append(inventory.Forms, form)
+				for _, provider := range providers {
+					addProvider(provider)
+				}
+				// Forms do not nest, so nothing below is outside a form
+				return
+			case "input":
+				if strings.EqualFold(getAttr(n, "type"), "password") && !hasAttr(n, "form") &&
+					!strings.Contains(strings.ToLower(getAttr(n, "autocomplete")), "new-password") {
+					inventory.PasswordFieldsOutsideForms++
+				}
+			case "a", "button":
+				addProvider(ssoProvider(n))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return inventory
+}
+
+// hasLogin reports whether a page offers a way to sign in.
+func hasLogin(inventory models.FormInventory) bool {
+	for _, form := range inventory.Forms {
+		if form.Classification == models.FormLogin {
+			return true
+		}
+	}
+	return inventory.PasswordFieldsOutsideForms > 0 || len(inventory.SSOProviders) > 0
+}
+
+// formSignals are the features of a form used to classify it.
+type formSignals struct {
+	attrs            string // action, id, class, name and aria-label of the form, and its submit button texts
+	submitTexts      []string
+	ssoSubmits       int    // submit buttons that are "Sign in with ..." buttons
+	text             string // all text of the form, including labels
+	passwords        int
+	newPasswords     int
+	textInputs       int
+	emailInputs      int
+	searchInputs     int
+	textareas        int
+	usernameField    bool
+	usernameAutofill bool
+	paymentField     bool
+	searchRole       bool
+}
+
+// inspectForm describes a form and classifies it. It also returns the providers of the
+// "Sign in with ..." links and buttons within the form.
+func inspectForm(n *html.Node, pageURL *url.URL) (models.Form, []string) {
+	action := resolveURL(pageURL, strings.TrimSpace(getAttr(n, "action")))
+	method := strings.ToUpper(strings.TrimSpace(getAttr(n, "method")))
+	if method == "" {
+		method = "GET"
+	}
+	form := models.Form{
+		Action:      action,
+		Method:      method,
+		HTTPSAction: strings.HasPrefix(strings.ToLower(action), "https:"),
+		Fields:      []models.FormField{},
+	}
+
+	signals := formSignals{
+		text:       strings.ToLower(textContent(n)),
+		searchRole: getAttr(n, "role") == "search",
+	}
+	attrs := []string{getAttr(n, "action"), getAttr(n, "id"), getAttr(n, "class"), getAttr(n, "name"), getAttr(n, "aria-label")}
+	var providers []string
+
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			switch c.Data {
+			case "input":
+				inputType := strings.ToLower(strings.TrimSpace(getAttr(c, "type")))
+				if inputType == "" {
+					inputType = "text"
+				}
+				if buttonInputTypes[inputType] {
+					if inputType == "submit" || inputType == "image" {
+						signals.submitTexts = append(signals.submitTexts, strings.TrimSpace(getAttr(c, "value")+" "+getAttr(c, "alt")))
+						if provider := ssoProvider(c); provider != "" {
+							providers = append(providers, provider)
+							signals.ssoSubmits++
+						}
+					}
+					break
+				}
+				field := newFormField(c, inputType)
+				form.Fields = append(form.Fields, field)
+				if inputType == "hidden" && csrfFieldPattern.MatchString(field.Name) {
+					form.HasCSRFToken = true
+				}
+				signals.addField(c, field)
+			case "select", "textarea":
+				field := newFormField(c, c.Data)
+				form.Fields = append(form.Fields, field)
+				signals.addField(c, field)
+			case "button":
+				provider := ssoProvider(c)
+				if provider != "" {
+					providers = append(providers, provider)
+				}
+				buttonType := strings.ToLower(getAttr(c, "type"))
+				if buttonType == "" || buttonType == "submit" {
+					signals.submitTexts = append(signals.submitTexts, textContent(c))
+					if provider != "" {
+						signals.ssoSubmits++
+					}
+				}
+			case "a":
+				if provider := ssoProvider(c); provider != "" {
+					providers = append(providers, provider)
+				}
+			case "meta":
+				// Some frameworks place the token in a <meta> inside the form
+				if csrfFieldPattern.MatchString(getAttr(c, "name")) {
+					form.HasCSRFToken = true
+				}
+			}
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	signals.attrs = strings.Join(append(attrs, signals.submitTexts...), " ")
+	form.Classification = classifyForm(signals)
+	return form, providers
+}
+
+func newFormField(n *html.Node, fieldType string) models.FormField {
+	name := getAttr(n, "name")
+	if name == "" {
+		name = getAttr(n, "id")
+	}
+	return models.FormField{
+		Type:         fieldType,
+		Name:         name,
+		Autocomplete: strings.ToLower(strings.TrimSpace(getAttr(n, "autocomplete"))),
+	}
+}
+
+// addField records the classification signals of a form field.
+func (s *formSignals) addField(n *html.Node, field models.FormField) {
+	identity := field.Name + " " + getAttr(n, "id") + " " + getAttr(n, "placeholder")
+	switch field.Type {
+	case "password":
+		s.passwords++
+		if strings.Contains(field.Autocomplete, "new-password") {
+			s.newPasswords++
+		}
+	case "textarea":
+		s.textareas++
+	case "search":
+		s.searchInputs++
+	}
+	if textInputTypes[field.Type] {
+		s.textInputs++
+		if field.Type == "email" || strings.Contains(field.Autocomplete, "email") {
+			s.emailInputs++
+		}
+		if field.Type == "text" && searchFieldPattern.MatchString(field.Name) {
+			s.searchInputs++
+		}
+		if usernameFieldPattern.MatchString(identity) || field.Type == "email" {
+			s.usernameField = true
+		}
+		if strings.Contains(field.Autocomplete, "username") {
+			s.usernameField = true
+			s.usernameAutofill = true
+		}
+	}
+	if strings.HasPrefix(field.Autocomplete, "cc-") || strings.Contains(field.Autocomplete, " cc-") || paymentFieldPattern.MatchString(identity) {
+		s.paymentField = true
+	}
+}
+
+// classifyForm picks the most specific purpose a form's signals support.
+func classifyForm(s formSignals) string {
+	switch {
+	case s.paymentField:
+		return models.FormPayment
+	case s.newPasswords > 0 || s.passwords >= 2 || (s.passwords > 0 && signupKeywords.MatchString(s.attrs) && !loginKeywords.MatchString(s.attrs)):
+		return models.FormSignup
+	case s.passwords > 0:
+		return models.FormLogin
+	case s.isEmailFirstLogin():
+		return models.FormLogin
+	case s.ssoSubmits > 0 && s.ssoSubmits == len(s.submitTexts):
+		// The form only submits to a "Sign in with ..." provider
+		return models.FormLogin
+	case s.searchRole || s.searchInputs > 0 || (s.textInputs == 1 && searchKeywords.MatchString(s.attrs)):
+		return models.FormSearch
+	case s.textareas > 0 && (s.emailInputs > 0 || contactKeywords.MatchString(s.text)):
+		return models.FormContact
+	case s.emailInputs > 0 && newsletterKeywords.MatchString(s.text+" "+s.attrs):
+		return models.FormNewsletter
+	case signupKeywords.MatchString(s.attrs) && s.emailInputs > 0:
+		return models.FormSignup
+	case contactKeywords.MatchString(s.attrs):
+		return models.FormContact
+	default:
+		return models.FormOther
+	}
+}
+
+// isEmailFirstLogin reports whether a form is the first step of a multi-step login: a single
+// username or email field submitted to a sign-in action, or autofilled as a username and
+// submitted with "Next" or "Continue".
+func (s formSignals) isEmailFirstLogin() bool {
+	if s.textInputs != 1 || !s.usernameField || newsletterKeywords.MatchString(s.attrs) {
+		return false
+	}
+	if loginKeywords.MatchString(s.attrs) {
+		return true
+	}
+	if !s.usernameAutofill {
+		return false
+	}
+	for _, text := range s.submitTexts {
+		if nextStepKeywords.MatchString(strings.TrimSpace(text)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ssoProvider returns the provider of a "Sign in with ..." link or button, lowercased,
+// or an empty string if n is not one.
+func ssoProvider(n *html.Node) string {
+	text := textContent(n)
+	if n.Data == "input" {
+		text = strings.TrimSpace(getAttr(n, "value"))
+	}
+	if text == "" {
+		text = strings.TrimSpace(getAttr(n, "aria-label"))
+	}
+	if len(text) > maxSSOButtonLength {
+		return ""
+	}
+	match := ssoPattern.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(match[1], "."))
+}
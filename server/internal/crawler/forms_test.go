@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestInventoryForms(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<form action="/session" method="post">
+  <input type="hidden" name="authenticity_token" value="abc">
+  <input type="email" name="email" autocomplete="username">
+  <input type="password" name="password" autocomplete="current-password">
+  <button>Sign in</button>
+</form>
+<form action="http://example.com/users" method="post">
+  <input type="email" name="email">
+  <input type="password" name="password" autocomplete="new-password">
+  <button type="submit">Create account</button>
+</form>
+<form action="/search" role="search"><input name="q"></form>
+<form action="/subscribe" method="post"><label>Our newsletter <input type="email" name="email"></label><button>Subscribe</button></form>
+<form action="/contact" method="post"><input type="email" name="email"><textarea name="message"></textarea><button>Send</button></form>
+<form action="/checkout" method="post"><input name="cardnumber" autocomplete="cc-number"><input name="cvc"><button>Pay</button></form>
+<form action="/login/identifier" method="post"><input type="text" name="identifier" autocomplete="username"><button>Next</button></form>
+<form><select name="lang"><option>en</option></select></form>
+</body></html>`))
+	require.NoError(t, err)
+
+	inventory := inventoryForms(doc, mustParseURL(t, "https://example.com/page"))
+
+	require.Len(t, inventory.Forms, 8)
+	var classes []string
+	for _, form := range inventory.Forms {
+		classes = append(classes, form.Classification)
+	}
+	assert.Equal(t, []string{
+		models.FormLogin, models.FormSignup, models.FormSearch, models.FormNewsletter,
+		models.FormContact, models.FormPayment, models.FormLogin, models.FormOther,
+	}, classes)
+
+	login := inventory.Forms[0]
+	assert.Equal(t, "https://example.com/session", login.Action)
+	assert.Equal(t, "POST", login.Method)
+	assert.True(t, login.HTTPSAction)
+	assert.True(t, login.HasCSRFToken)
+	assert.Equal(t, []models.FormField{
+		{Type: "hidden", Name: "authenticity_token"},
+		{Type: "email", Name: "email", Autocomplete: "username"},
+		{Type: "password", Name: "password", Autocomplete: "current-password"},
+	}, login.Fields)
+
+	signup := inventory.Forms[1]
+	assert.False(t, signup.HTTPSAction)
+	assert.False(t, signup.HasCSRFToken)
+
+	search := inventory.Forms[2]
+	assert.Equal(t, "GET", search.Method)
+	assert.Equal(t, "https://example.com/search", search.Action)
+
+	assert.Equal(t, "https://example.com/page", inventory.Forms[7].Action)
+	assert.Zero(t, inventory.PasswordFieldsOutsideForms)
+	assert.Empty(t, inventory.SSOProviders)
+	assert.True(t, hasLogin(inventory))
+}
+
+func TestHasLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"signup only", `<form><input type="password" name="p1"><input type="password" name="p2"></form>`, false},
+		{"password outside forms", `<div><input type="text" name="user"><input type="password"></div>`, true},
+		{"new password outside forms", `<div><input type="password" autocomplete="new-password"></div>`, false},
+		{"sso buttons", `<a href="/auth/google">Sign in with Google</a><button>Continue with Apple</button>`, true},
+		{"sign up with sso", `<a href="/auth/google">Sign up with Google</a>`, false},
+		{"email-first login", `<form action="/signin"><input type="email" name="email"><button>Next</button></form>`, true},
+		{"newsletter", `<form><input type="email" name="email" autocomplete="email"><button>Subscribe</button></form>`, false},
+		{"no forms", `<p>Nothing to see</p>`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(`<html><body>` + tt.body + `</body></html>`))
+			require.NoError(t, err)
+
+			inventory := inventoryForms(doc, mustParseURL(t, "https://example.com/"))
+			assert.Equal(t, tt.expected, hasLogin(inventory))
+		})
+	}
+}
+
+func TestInventoryForms_SSOProviders(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<a href="/auth/google">Sign in with Google</a>
+<a href="/auth/google?popup=1">Log in with <b>Google</b></a>
+<button aria-label="Sign in with GitHub"><svg></svg></button>
+</body></html>`))
+	require.NoError(t, err)
+
+	inventory := inventoryForms(doc, mustParseURL(t, "https://example.com/"))
+
+	assert.Equal(t, []string{"google", "github"}, inventory.SSOProviders)
+}
+
+func TestInventoryForms_SSOButtonsInForms(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<form action="/auth/google"><button type="submit">Sign in with Google</button></form>
+<form action="/auth/apple" method="post"><input type="submit" value="Continue with Apple"></form>
+<form action="/subscribe"><input type="email" name="email"><button>Subscribe</button><a href="/auth/github">Sign in with GitHub</a></form>
+</body></html>`))
+	require.NoError(t, err)
+
+	inventory := inventoryForms(doc, mustParseURL(t, "https://example.com/"))
+
+	assert.Equal(t, []string{"google", "apple", "github"}, inventory.SSOProviders)
+	require.Len(t, inventory.Forms, 3)
+	// A form whose only submit is a "Sign in with ..." button is a login form
+	assert.Equal(t, models.FormLogin, inventory.Forms[0].Classification)
+	assert.Equal(t, models.FormLogin, inventory.Forms[1].Classification)
+	assert.Equal(t, models.FormNewsletter, inventory.Forms[2].Classification)
+	assert.True(t, hasLogin(inventory))
+}
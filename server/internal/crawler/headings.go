@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// maxHeadingLength is the number of characters above which a heading is reported as too long.
+const maxHeadingLength = 70
+
+// headingText returns the text of a heading element. Headings consisting only of images
+// (a common pattern for logos) fall back to the images' alt text.
+func headingText(n *html.Node) string {
+	if text := textContent(n); text != "" {
+		return text
+	}
+
+	var alts []string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			if alt := strings.TrimSpace(getAttr(n, "alt")); alt != "" {
+				alts = append(alts, alt)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(alts, " ")
+}
+
+// validateOutline checks a heading outline for a missing or repeated H1, skipped levels,
+// empty headings and overly long headings.
+func validateOutline(outline models.HeadingList) models.HeadingIssues {
+	issues := models.HeadingIssues{}
+
+	h1Count := 0
+	for i, heading := range outline {
+		if heading.Level == 1 {
+			h1Count++
+		}
+		if i > 0 && heading.Level > outline[i-1].Level+1 {
+			issues.SkippedLevels = append(issues.SkippedLevels, models.HeadingSkip{
+				Position: heading.Position,
+				From:     outline[i-1].Level,
+				To:       heading.Level,
+			})
+		}
+		if heading.Text == "" {
+			issues.EmptyHeadings = append(issues.EmptyHeadings, heading.Position)
+		}
+		if utf8.RuneCountInString(heading.Text) > maxHeadingLength {
+			issues.LongHeadings = append(issues.LongHeadings, heading.Position)
+		}
+	}
+
+	issues.MissingH1 = h1Count == 0
+	issues.MultipleH1 = h1Count > 1
+	return issues
+}
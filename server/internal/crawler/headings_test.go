@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestExtractInfo_HeadingOutline(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<!DOCTYPE html>
+<html><body>
+<h2>  Getting
+  started </h2>
+<h4></h4>
+<h1><img src="/logo.png" alt="Acme"></h1>
+<h1>` + strings.Repeat("Very long heading ", 5) + `</h1>
+</body></html>`))
+	require.NoError(t, err)
+
+	result := &models.CrawlResult{URL: "http://example.com", Headings: make(map[string]int)}
+	extractInfo(doc, result)
+
+	assert.Equal(t, models.HeadingList{
+		{Level: 2, Text: "Getting started", Position: 0},
+		{Level: 4, Text: "", Position: 1},
+		{Level: 1, Text: "Acme", Position: 2},
+		{Level: 1, Text: strings.TrimSpace(strings.Repeat("Very long heading ", 5)), Position: 3},
+	}, result.Outline)
+}
+
+func TestValidateOutline(t *testing.T) {
+	tests := []struct {
+		name     string
+		outline  models.HeadingList
+		expected models.HeadingIssues
+	}{
+		{
+			name: "Well-formed outline",
+			outline: models.HeadingList{
+				{Level: 1, Text: "Title", Position: 0},
+				{Level: 2, Text: "Section", Position: 1},
+				{Level: 3, Text: "Subsection", Position: 2},
+				{Level: 2, Text: "Section", Position: 3},
+			},
+			expected: models.HeadingIssues{},
+		},
+		{
+			name:     "No headings",
+			outline:  models.HeadingList{},
+			expected: models.HeadingIssues{MissingH1: true},
+		},
+		{
+			name: "Multiple H1s and a skipped level",
+			outline: models.HeadingList{
+				{Level: 1, Text: "Title", Position: 0},
+				{Level: 2, Text: "Section", Position: 1},
+				{Level: 4, Text: "Detail", Position: 2},
+				{Level: 1, Text: "Another title", Position: 3},
+			},
+			expected: models.HeadingIssues{
+				MultipleH1:    true,
+				SkippedLevels: []models.HeadingSkip{{Position: 2, From: 2, To: 4}},
+			},
+		},
+		{
+			name: "Empty and long headings",
+			outline: models.HeadingList{
+				{Level: 1, Text: "", Position: 0},
+				{Level: 2, Text: strings.Repeat("x", maxHeadingLength+1), Position: 1},
+				{Level: 2, Text: strings.Repeat("ü", maxHeadingLength), Position: 2},
+			},
+			expected: models.HeadingIssues{
+				EmptyHeadings: []int{0},
+				LongHeadings:  []int{1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, validateOutline(tt.outline))
+		})
+	}
+}
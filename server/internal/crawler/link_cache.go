@@ -0,0 +1,209 @@
+package crawler
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLinkCacheEntries bounds the in-memory tier. When it is reached, expired entries are swept
+// and, if the cache is still too large, the oldest entries are evicted down to
+// linkCacheEvictionTarget, so that evictions are not repeated on every insertion.
+const (
+	maxLinkCacheEntries     = 100000
+	linkCacheEvictionTarget = maxLinkCacheEntries * 9 / 10
+)
+
+// LinkStatusStore is a persistent tier of the link status cache, shared between crawler
+// processes. *database.DB implements it.
+type LinkStatusStore interface {
+	GetLinkStatus(url string, notBefore time.Time) (status int, checkedAt time.Time, found bool, err error)
+	SaveLinkStatus(url string, status int, checkedAt time.Time) error
+	// DeleteLinkStatuses removes the statuses checked before checkedBefore.
+	DeleteLinkStatuses(checkedBefore time.Time) (int64, error)
+}
+
+// LinkCacheStats are the totals of a LinkCache since it was created.
+type LinkCacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+type linkCacheEntry struct {
+	status    int
+	checkedAt time.Time
+}
+
+// linkCheck is a check of a URL in progress, which concurrent lookups of the URL wait for.
+type linkCheck struct {
+	done   chan struct{}
+	status int
+}
+
+// LinkCache caches the status codes of checked URLs for a time to live, so that links shared
+// by many pages of a site, such as navigation and footer links, are checked once per window.
+// It is safe for concurrent use by the workers sharing a Crawler.
+type LinkCache struct {
+	ttl   time.Duration
+	store LinkStatusStore
+	now   func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]linkCacheEntry
+	inFlight map[string]*linkCheck
+	hits     int64
+	misses   int64
+	prunedAt time.Time // when expired statuses were last deleted from the store
+}
+
+// NewLinkCache creates a LinkCache keeping statuses for ttl. store, if not nil, is consulted on
+// a miss of the in-memory tier and receives every fresh status.
+func NewLinkCache(ttl time.Duration, store LinkStatusStore) *LinkCache {
+	return &LinkCache{
+		ttl:      ttl,
+		store:    store,
+		now:      time.Now,
+		entries:  map[string]linkCacheEntry{},
+		inFlight: map[string]*linkCheck{},
+	}
+}
+
+// Status returns the status of url, calling check if no status was cached within the time to
+// live or if bypass is set. Concurrent lookups of the same URL share a single check, except that
+// a bypassing lookup never joins a check in progress. hit reports whether the status came from
+// the cache. A status of 0, for a URL that could not be reached, is not cached, so that
+// transient network errors are retried by the next job.
+func (c *LinkCache) Status(url string, bypass bool, check func(url string) int) (status int, hit bool) {
+	if bypass {
+		status = check(url)
+		c.record(url, status, c.now(), false)
+		return status, false
+	}
+
+	c.mu.Lock()
+	if entry, ok := c.entries[url]; ok && c.now().Sub(entry.checkedAt) < c.ttl {
+		c.hits++
+		c.mu.Unlock()
+		return entry.status, true
+	}
+	if pending, ok := c.inFlight[url]; ok {
+		c.hits++
+		c.mu.Unlock()
+		<-pending.done
+		return pending.status, true
+	}
+	pending := &linkCheck{done: make(chan struct{})}
+	c.inFlight[url] = pending
+	c.mu.Unlock()
+
+	status, checkedAt, hit := c.lookupStore(url)
+	if !hit {
+		status, checkedAt = check(url), c.now()
+	}
+	c.record(url, status, checkedAt, hit)
+
+	c.mu.Lock()
+	delete(c.inFlight, url)
+	c.mu.Unlock()
+	pending.status = status
+	close(pending.done)
+	return status, hit
+}
+
+// record counts a lookup and caches its status, writing fresh statuses to the persistent tier.
+// Unreachable URLs, with status 0, are not cached.
+func (c *LinkCache) record(url string, status int, checkedAt time.Time, hit bool) {
+	if !hit && status != 0 {
+		c.saveStore(url, status, checkedAt)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	if status != 0 {
+		if _, ok := c.entries[url]; !ok && len(c.entries) >= maxLinkCacheEntries {
+			c.evict()
+		}
+		c.entries[url] = linkCacheEntry{status: status, checkedAt: checkedAt}
+	}
+}
+
+// Stats returns the hits and misses of the cache so far and its number of in-memory entries.
+func (c *LinkCache) Stats() LinkCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return LinkCacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// lookupStore returns the status of url from the persistent tier, if there is one.
+func (c *LinkCache) lookupStore(url string) (int, time.Time, bool) {
+	if c.store == nil {
+		return 0, time.Time{}, false
+	}
+	status, checkedAt, found, err := c.store.GetLinkStatus(url, c.now().Add(-c.ttl))
+	if err != nil {
+		log.Printf("Error reading cached status of %s: %v\n", url, err)
+		return 0, time.Time{}, false
+	}
+	return status, checkedAt, found
+}
+
+// saveStore writes a fresh status to the persistent tier, if there is one.
+func (c *LinkCache) saveStore(url string, status int, checkedAt time.Time) {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.SaveLinkStatus(url, status, checkedAt); err != nil {
+		log.Printf("Error caching status of %s: %v\n", url, err)
+	}
+	c.pruneStore()
+}
+
+// pruneStore deletes the expired statuses of the persistent tier, at most once per time to
+// live, so that the store does not keep a row for every URL ever checked.
+func (c *LinkCache) pruneStore() {
+	now := c.now()
+	c.mu.Lock()
+	due := now.Sub(c.prunedAt) >= c.ttl
+	if due {
+		c.prunedAt = now
+	}
+	c.mu.Unlock()
+	if !due {
+		return
+	}
+	if _, err := c.store.DeleteLinkStatuses(now.Add(-c.ttl)); err != nil {
+		log.Printf("Error deleting expired link statuses: %v\n", err)
+	}
+}
+
+// evict removes expired entries and then, if the cache is still above linkCacheEvictionTarget,
+// the oldest entries. c.mu must be held.
+func (c *LinkCache) evict() {
+	now := c.now()
+	for url, entry := range c.entries {
+		if now.Sub(entry.checkedAt) >= c.ttl {
+			delete(c.entries, url)
+		}
+	}
+	if len(c.entries) <= linkCacheEvictionTarget {
+		return
+	}
+
+	urls := make([]string, 0, len(c.entries))
+	for url := range c.entries {
+		urls = append(urls, url)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return c.entries[urls[i]].checkedAt.Before(c.entries[urls[j]].checkedAt)
+	})
+	for _, url := range urls[:len(urls)-linkCacheEvictionTarget] {
+		delete(c.entries, url)
+	}
+}
@@ -0,0 +1,243 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+// fakeLinkStatusStore is an in-memory LinkStatusStore.
+type fakeLinkStatusStore struct {
+	mu       sync.Mutex
+	statuses map[string]linkCacheEntry
+}
+
+func (s *fakeLinkStatusStore) GetLinkStatus(url string, notBefore time.Time) (int, time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.statuses[url]
+	if !ok || entry.checkedAt.Before(notBefore) {
+		return 0, time.Time{}, false, nil
+	}
+	return entry.status, entry.checkedAt, true, nil
+}
+
+func (s *fakeLinkStatusStore) SaveLinkStatus(url string, status int, checkedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[url] = linkCacheEntry{status: status, checkedAt: checkedAt}
+	return nil
+}
+
+func (s *fakeLinkStatusStore) DeleteLinkStatuses(checkedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for url, entry := range s.statuses {
+		if entry.checkedAt.Before(checkedBefore) {
+			delete(s.statuses, url)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestLinkCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewLinkCache(time.Minute, nil)
+	cache.now = func() time.Time { return now }
+
+	checks := 0
+	check := func(url string) int {
+		checks++
+		return map[string]int{"https://example.com/ok": 200, "https://example.com/gone": 404}[url]
+	}
+
+	status, hit := cache.Status("https://example.com/ok", false, check)
+	assert.Equal(t, 200, status)
+	assert.False(t, hit)
+
+	// A second lookup within the time to live is served from the cache
+	status, hit = cache.Status("https://example.com/ok", false, check)
+	assert.Equal(t, 200, status)
+	assert.True(t, hit)
+	assert.Equal(t, 1, checks)
+
+	// Bypassing the cache checks again
+	_, hit = cache.Status("https://example.com/ok", true, check)
+	assert.False(t, hit)
+	assert.Equal(t, 2, checks)
+
+	// Expired statuses are checked again
+	now = now.Add(time.Minute)
+	_, hit = cache.Status("https://example.com/ok", false, check)
+	assert.False(t, hit)
+	assert.Equal(t, 3, checks)
+
+	// Unreachable URLs are not cached
+	status, _ = cache.Status("https://example.com/unreachable", false, check)
+	assert.Equal(t, 0, status)
+	_, hit = cache.Status("https://example.com/unreachable", false, check)
+	assert.False(t, hit)
+
+	assert.Equal(t, LinkCacheStats{Hits: 1, Misses: 5, Entries: 1}, cache.Stats())
+}
+
+func TestLinkCache_ConcurrentLookups(t *testing.T) {
+	cache := NewLinkCache(time.Minute, nil)
+	release := make(chan struct{})
+	var checks atomic.Int32
+	check := func(url string) int {
+		checks.Add(1)
+		<-release
+		return 200
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _ := cache.Status("https://example.com/", false, check)
+			assert.Equal(t, 200, status)
+		}()
+	}
+	// Wait for the first lookup to start checking before letting it finish
+	require.Eventually(t, func() bool { return checks.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), checks.Load())
+	assert.Equal(t, LinkCacheStats{Hits: 9, Misses: 1, Entries: 1}, cache.Stats())
+}
+
+func TestLinkCache_BypassSkipsCheckInProgress(t *testing.T) {
+	cache := NewLinkCache(time.Minute, nil)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go cache.Status("https://example.com/", false, func(string) int {
+		close(started)
+		<-release
+		return 500
+	})
+	<-started
+
+	// The bypassing lookup checks afresh instead of waiting for the stale check
+	status, hit := cache.Status("https://example.com/", true, func(string) int { return 200 })
+	assert.Equal(t, 200, status)
+	assert.False(t, hit)
+	close(release)
+}
+
+func TestLinkCache_Eviction(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewLinkCache(time.Hour, nil)
+	cache.now = func() time.Time { return now }
+	for i := 0; i < maxLinkCacheEntries; i++ {
+		cache.entries[fmt.Sprintf("https://example.com/%d", i)] = linkCacheEntry{status: 200, checkedAt: now.Add(-time.Duration(i) * time.Millisecond)}
+	}
+
+	// Every entry is fresh, so the oldest ones are evicted to make room
+	cache.Status("https://example.com/new", false, func(string) int { return 200 })
+	assert.Equal(t, linkCacheEvictionTarget+1, cache.Stats().Entries)
+	assert.Contains(t, cache.entries, "https://example.com/0")
+	assert.Contains(t, cache.entries, "https://example.com/new")
+	assert.NotContains(t, cache.entries, fmt.Sprintf("https://example.com/%d", maxLinkCacheEntries-1))
+}
+
+func TestLinkCache_Store(t *testing.T) {
+	store := &fakeLinkStatusStore{statuses: map[string]linkCacheEntry{}}
+	check := func(url string) int { return 500 }
+
+	// A status checked through one cache is found by another sharing the store
+	first := NewLinkCache(time.Minute, store)
+	_, hit := first.Status("https://example.com/", false, check)
+	assert.False(t, hit)
+
+	second := NewLinkCache(time.Minute, store)
+	status, hit := second.Status("https://example.com/", false, func(string) int {
+		t.Error("unexpected check")
+		return 0
+	})
+	assert.Equal(t, 500, status)
+	assert.True(t, hit)
+
+	// Stale statuses in the store are ignored
+	third := NewLinkCache(time.Minute, store)
+	third.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	_, hit = third.Status("https://example.com/", false, check)
+	assert.False(t, hit)
+}
+
+func TestLinkCache_PrunesStore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeLinkStatusStore{statuses: map[string]linkCacheEntry{
+		"https://example.com/old": {status: 200, checkedAt: now.Add(-2 * time.Minute)},
+	}}
+	cache := NewLinkCache(time.Minute, store)
+	cache.now = func() time.Time { return now }
+	check := func(string) int { return 200 }
+
+	// Saving a fresh status deletes the expired ones
+	cache.Status("https://example.com/a", false, check)
+	assert.NotContains(t, store.statuses, "https://example.com/old")
+	assert.Contains(t, store.statuses, "https://example.com/a")
+
+	// The store is pruned at most once per time to live
+	store.statuses["https://example.com/old"] = linkCacheEntry{status: 200, checkedAt: now.Add(-2 * time.Minute)}
+	cache.Status("https://example.com/b", false, check)
+	assert.Contains(t, store.statuses, "https://example.com/old")
+
+	now = now.Add(2 * time.Minute)
+	cache.Status("https://example.com/c", false, check)
+	assert.NotContains(t, store.statuses, "https://example.com/old")
+	assert.NotContains(t, store.statuses, "https://example.com/a")
+}
+
+func TestCrawl_LinkCache(t *testing.T) {
+	var heads atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path != "/" {
+			heads.Add(1)
+		}
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html><html><body><a href="/about">About</a><a href="/gone">Gone</a></body></html>`))
+			assert.NoError(t, err)
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := newTestCrawler(Config{})
+	crawl := func(options models.CrawlOptions) *models.CrawlResult {
+		result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int), Options: options}
+		require.NoError(t, c.Crawl(result))
+		assert.Equal(t, 1, result.InaccessibleLinksCount)
+		return result
+	}
+
+	result := crawl(models.CrawlOptions{})
+	assert.Equal(t, models.LinkCacheStats{Misses: 2}, result.LinkCacheStats)
+	assert.Equal(t, int32(2), heads.Load())
+
+	// The links of a second crawl with the same crawler are served from the cache
+	result = crawl(models.CrawlOptions{})
+	assert.Equal(t, models.LinkCacheStats{Hits: 2}, result.LinkCacheStats)
+	assert.Equal(t, int32(2), heads.Load())
+
+	result = crawl(models.CrawlOptions{BypassLinkCache: true})
+	assert.Equal(t, models.LinkCacheStats{Misses: 2, Bypassed: true}, result.LinkCacheStats)
+	assert.Equal(t, int32(4), heads.Load())
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/hostname"
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// fragmentScheme is the LinkSchemes key of same-page links, i.e. empty or fragment-only hrefs.
+const fragmentScheme = "fragment"
+
+// classifyLinks counts the links of a page by scheme, its same-page links separately, and its
+// other http and https links as internal or external to page according to the link scope of
+// the job. hrefs are resolved against base. It returns the resolved http and https links, the
+// only ones checked; mailto:, tel:, javascript: and other links are counted but not checked.
+// hrefs that cannot be resolved are added to the link findings of the result.
+func classifyLinks(hrefs []string, base, page *url.URL, result *models.CrawlResult) []string {
+	var links []string
+	result.LinkSchemes = models.JSONMap{}
+	scope := newLinkScope(page, result.Options)
+	for _, href := range hrefs {
+		href = strings.TrimSpace(href)
+		link, err := url.Parse(href)
+		if err != nil {
+			result.LinkFindings = append(result.LinkFindings, models.Finding{Code: "href_unresolvable", Severity: models.SeverityWarning, Message: fmt.Sprintf("Link href %q cannot be parsed: %v", href, err)})
+			continue
+		}
+		resolved := base.ResolveReference(link)
+		scheme := strings.ToLower(resolved.Scheme)
+		if scheme != "http" && scheme != "https" {
+			result.LinkSchemes[scheme]++
+			continue
+		}
+		if resolved.Host == "" {
+			result.LinkFindings = append(result.LinkFindings, models.Finding{Code: "href_unresolvable", Severity: models.SeverityWarning, Message: fmt.Sprintf("Link href %q resolves to %q, which has no host", href, resolved.String())})
+			continue
+		}
+		links = append(links, resolved.String())
+
+		switch {
+		case (href == "" || strings.HasPrefix(href, "#")) && withoutFragment(resolved) == withoutFragment(page):
+			result.LinkSchemes[fragmentScheme]++
+		case scope.isInternal(resolved.Hostname()):
+			result.LinkSchemes[scheme]++
+			result.InternalLinksCount++
+		default:
+			result.LinkSchemes[scheme]++
+			result.ExternalLinksCount++
+		}
+	}
+	return links
+}
+
+// linkScope decides which hosts are internal to a page.
+type linkScope struct {
+	scope         string
+	host          string
+	domain        string
+	internalHosts []string
+}
+
+// newLinkScope returns the link scope of a page from the options of its job.
+func newLinkScope(page *url.URL, options models.CrawlOptions) linkScope {
+	s := linkScope{scope: options.LinkScope, host: asciiHost(page.Hostname())}
+	s.domain = registrableDomain(s.host)
+	for _, host := range options.InternalHosts {
+		pattern, err := hostname.ParsePattern(host)
+		if err != nil {
+			pattern = hostname.Normalize(host)
+		}
+		s.internalHosts = append(s.internalHosts, pattern)
+	}
+	return s
+}
+
+// asciiHost normalizes a host to the punycode form pages are stored in, so that internationalized
+// names compare equal however they are written. Invalid hosts are only normalized.
+func asciiHost(host string) string {
+	ascii, err := hostname.ToASCII(host)
+	if err != nil {
+		return hostname.Normalize(host)
+	}
+	return ascii
+}
+
+// isInternal reports whether links to host are internal. In the subdomains scope, the www host
+// and the apex domain are the same site, so a page on www.example.com and one on example.com
+// both have example.com, www.example.com and blog.example.com as internal hosts.
+func (s linkScope) isInternal(host string) bool {
+	host = asciiHost(host)
+	if hostname.MatchAny(s.internalHosts, host) {
+		return true
+	}
+	switch s.scope {
+	case models.LinkScopeSubdomains:
+		return hostname.Match("*."+strings.TrimPrefix(s.host, "www."), host)
+	case models.LinkScopeDomain:
+		return registrableDomain(host) == s.domain
+	default:
+		return host == s.host
+	}
+}
+
+// registrableDomain returns the registrable domain of a host, the public suffix plus one label,
+// e.g. "example.co.uk" for "blog.example.co.uk". Hosts without one, such as IP addresses and
+// "localhost", are their own domain.
+func registrableDomain(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestClassifyLinks(t *testing.T) {
+	hrefs := []string{
+		"/about",
+		"#top",
+		"",
+		"mailto:team@example.co.uk",
+		"tel:+441234567890",
+		"javascript:void(0)",
+		"HTTPS://WWW.EXAMPLE.CO.UK/",
+		"http://example.co.uk/",
+		"https://blog.www.example.co.uk/post",
+		"https://shop.example.co.uk/",
+		"https://partner.org/",
+		"https://cdn.partner.net/asset",
+		"//other.com/protocol-relative",
+	}
+	base := mustParseURL(t, "https://www.example.co.uk/page")
+
+	tests := []struct {
+		name     string
+		options  models.CrawlOptions
+		internal int
+		external int
+	}{
+		{name: "exact host by default", internal: 2, external: 6},
+		{name: "subdomains of the apex domain", options: models.CrawlOptions{LinkScope: models.LinkScopeSubdomains}, internal: 5, external: 3},
+		{name: "registrable domain", options: models.CrawlOptions{LinkScope: models.LinkScopeDomain}, internal: 5, external: 3},
+		{
+			name:     "allow list",
+			options:  models.CrawlOptions{InternalHosts: []string{"Partner.org", "*.partner.net"}},
+			internal: 4,
+			external: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.CrawlResult{Options: tt.options}
+			links := classifyLinks(hrefs, base, base, result)
+
+			assert.Equal(t, tt.internal, result.InternalLinksCount)
+			assert.Equal(t, tt.external, result.ExternalLinksCount)
+			assert.Equal(t, models.JSONMap{"https": 7, "http": 1, "fragment": 2, "mailto": 1, "tel": 1, "javascript": 1}, result.LinkSchemes)
+			assert.Len(t, links, 10)
+			assert.NotContains(t, links, "mailto:team@example.co.uk")
+			assert.Contains(t, links, "https://www.example.co.uk/page#top")
+		})
+	}
+}
+
+func TestLinkScope_IsInternal(t *testing.T) {
+	tests := []struct {
+		page     string
+		scope    string
+		host     string
+		internal bool
+	}{
+		{page: "https://example.com/", scope: models.LinkScopeHost, host: "EXAMPLE.com.", internal: true},
+		{page: "https://example.com/", scope: models.LinkScopeHost, host: "www.example.com", internal: false},
+		{page: "https://example.com/", scope: models.LinkScopeSubdomains, host: "www.example.com", internal: true},
+		{page: "https://www.example.com/", scope: models.LinkScopeSubdomains, host: "example.com", internal: true},
+		{page: "https://www.example.com/", scope: models.LinkScopeSubdomains, host: "blog.example.com", internal: true},
+		{page: "https://blog.example.com/", scope: models.LinkScopeSubdomains, host: "example.com", internal: false},
+		{page: "https://www.example.com/", scope: models.LinkScopeSubdomains, host: "example.org", internal: false},
+		{page: "https://blog.example.com/", scope: models.LinkScopeDomain, host: "shop.example.com", internal: true},
+		{page: "https://xn--bcher-kva.example/", scope: models.LinkScopeHost, host: "Bücher.example", internal: true},
+		{page: "https://bücher.example/", scope: models.LinkScopeHost, host: "xn--bcher-kva.example", internal: true},
+		{page: "https://www.xn--bcher-kva.example/", scope: models.LinkScopeSubdomains, host: "shop.bücher.example", internal: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope+" "+tt.page+" "+tt.host, func(t *testing.T) {
+			scope := newLinkScope(mustParseURL(t, tt.page), models.CrawlOptions{LinkScope: tt.scope})
+			assert.Equal(t, tt.internal, scope.isInternal(tt.host))
+		})
+	}
+}
+
+func TestLinkScope_InternalHostsIDN(t *testing.T) {
+	page := mustParseURL(t, "https://example.com/")
+	scope := newLinkScope(page, models.CrawlOptions{InternalHosts: []string{"*.bücher.example", "xn--caf-dma.example"}})
+	assert.True(t, scope.isInternal("shop.xn--bcher-kva.example"))
+	assert.True(t, scope.isInternal("Shop.Bücher.example"))
+	assert.True(t, scope.isInternal("café.example"))
+	assert.False(t, scope.isInternal("other.example"))
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	assert.Equal(t, "example.co.uk", registrableDomain("blog.example.co.uk"))
+	assert.Equal(t, "example.com", registrableDomain("example.com"))
+	assert.Equal(t, "localhost", registrableDomain("localhost"))
+	assert.Equal(t, "127.0.0.1", registrableDomain("127.0.0.1"))
+}
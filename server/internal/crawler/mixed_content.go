@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+// mixedContentTypes maps resource kinds to the type of mixed content they are when referenced
+// over plain HTTP. Browsers block active mixed content and upgrade or load passive mixed
+// content; kinds not listed, such as canonical links, are not loaded by the page.
+var mixedContentTypes = map[string]string{
+	resourceScript:     models.MixedContentActive,
+	resourceStylesheet: models.MixedContentActive,
+	resourceIframe:     models.MixedContentActive,
+	resourceObject:     models.MixedContentActive,
+	resourceFont:       models.MixedContentActive,
+	resourceImage:      models.MixedContentPassive,
+	resourceMedia:      models.MixedContentPassive,
+	resourceForm:       models.MixedContentForm,
+}
+
+// findMixedContent returns the resources of a page referenced over plain HTTP.
+func findMixedContent(resources []resource) []models.MixedContent {
+	items := []models.MixedContent{}
+	for _, r := range resources {
+		contentType, ok := mixedContentTypes[r.Kind]
+		if !ok || !strings.HasPrefix(r.URL, "http:") {
+			continue
+		}
+		items = append(items, models.MixedContent{URL: r.URL, Kind: r.Kind, Type: contentType, Element: r.Tag + " " + r.Attr})
+	}
+	return items
+}
+
+// checkMixedContent reports the resources of an HTTPS page referenced over plain HTTP. A CSP
+// upgrade-insecure-requests directive makes browsers fetch them over HTTPS instead, so they
+// are then only noted.
+func (a *securityAudit) checkMixedContent(resources []resource) {
+	a.report.MixedContent = []models.MixedContent{}
+	if !a.report.HTTPS {
+		return
+	}
+	a.report.MixedContent = findMixedContent(resources)
+
+	counts := map[string]int{}
+	for _, item := range a.report.MixedContent {
+		counts[item.Type]++
+	}
+	upgraded := a.cspHas("upgrade-insecure-requests")
+	report := func(contentType string, points int, code, severity, format string) {
+		n := counts[contentType]
+		if n == 0 {
+			return
+		}
+		message := fmt.Sprintf(format, n)
+		if upgraded {
+			points, severity = 0, models.SeverityInfo
+			message += " (upgraded to HTTPS by upgrade-insecure-requests)"
+		}
+		a.deduct(points, code, severity, "%s", message)
+	}
+	report(models.MixedContentActive, 20, "mixed_content_active", models.SeverityError, "Active content (scripts, stylesheets, frames, fonts or plugins) loaded over plain HTTP: %d")
+	report(models.MixedContentPassive, 5, "mixed_content_passive", models.SeverityWarning, "Passive content (images or media) loaded over plain HTTP: %d")
+	report(models.MixedContentForm, 10, "mixed_content_form", models.SeverityWarning, "Forms submitting over plain HTTP: %d")
+}
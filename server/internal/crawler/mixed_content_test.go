@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+const mixedContentPage = `<!DOCTYPE html>
+<html><head>
+<link rel="stylesheet" href="http://cdn.example.com/style.css">
+<link rel="canonical" href="http://example.com/page">
+<script src="http://cdn.example.com/app.js"></script>
+<script src="https://cdn.example.com/safe.js"></script>
+<style>@font-face { font-family: F; src: url(http://fonts.example.com/f.woff2); }</style>
+</head><body>
+<img src="/local.png" srcset="http://img.example.com/a.png 1x, https://img.example.com/b.png 2x">
+<video src="http://media.example.com/clip.mp4"></video>
+<iframe src="http://widgets.example.com/embed"></iframe>
+<div style="background: url('http://img.example.com/bg.png')"></div>
+<form action="http://example.com/login"></form>
+<a href="http://example.com/plain-link">Links are not mixed content</a>
+</body></html>`
+
+func TestFindMixedContent(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(mixedContentPage))
+	require.NoError(t, err)
+
+	items := findMixedContent(collectResources(doc, mustParseURL(t, "https://example.com/")))
+
+	assert.Equal(t, []models.MixedContent{
+		{URL: "http://cdn.example.com/style.css", Kind: "stylesheet", Type: "active", Element: "link href"},
+		{URL: "http://cdn.example.com/app.js", Kind: "script", Type: "active", Element: "script src"},
+		{URL: "http://fonts.example.com/f.woff2", Kind: "font", Type: "active", Element: "style style"},
+		{URL: "http://img.example.com/a.png", Kind: "image", Type: "passive", Element: "img srcset"},
+		{URL: "http://media.example.com/clip.mp4", Kind: "media", Type: "passive", Element: "video src"},
+		{URL: "http://widgets.example.com/embed", Kind: "iframe", Type: "active", Element: "iframe src"},
+		{URL: "http://img.example.com/bg.png", Kind: "image", Type: "passive", Element: "div style"},
+		{URL: "http://example.com/login", Kind: "form", Type: "form", Element: "form action"},
+	}, items)
+}
+
+func TestAuditSecurity_MixedContent(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(mixedContentPage))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		csp      string
+		items    int
+		findings []models.Finding
+	}{
+		{
+			name:  "HTTPS page",
+			url:   "https://example.com/",
+			csp:   "default-src 'self'",
+			items: 8,
+			findings: []models.Finding{
+				{Code: "mixed_content_active", Severity: models.SeverityError, Message: "Active content (scripts, stylesheets, frames, fonts or plugins) loaded over plain HTTP: 4"},
+				{Code: "mixed_content_passive", Severity: models.SeverityWarning, Message: "Passive content (images or media) loaded over plain HTTP: 3"},
+				{Code: "mixed_content_form", Severity: models.SeverityWarning, Message: "Forms submitting over plain HTTP: 1"},
+			},
+		},
+		{
+			name:  "upgrade-insecure-requests",
+			url:   "https://example.com/",
+			csp:   "default-src 'self'; upgrade-insecure-requests",
+			items: 8,
+			findings: []models.Finding{
+				{Code: "mixed_content_active", Severity: models.SeverityInfo, Message: "Active content (scripts, stylesheets, frames, fonts or plugins) loaded over plain HTTP: 4 (upgraded to HTTPS by upgrade-insecure-requests)"},
+				{Code: "mixed_content_passive", Severity: models.SeverityInfo, Message: "Passive content (images or media) loaded over plain HTTP: 3 (upgraded to HTTPS by upgrade-insecure-requests)"},
+				{Code: "mixed_content_form", Severity: models.SeverityInfo, Message: "Forms submitting over plain HTTP: 1 (upgraded to HTTPS by upgrade-insecure-requests)"},
+			},
+		},
+		{
+			name:     "HTTP page",
+			url:      "http://example.com/",
+			csp:      "default-src 'self'",
+			findings: []models.Finding{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Content-Security-Policy", tt.csp)
+			resp := &http.Response{Header: header, Request: httptest.NewRequest(http.MethodGet, tt.url, nil)}
+
+			report := auditSecurity(resp, doc, collectResources(doc, mustParseURL(t, tt.url)))
+
+			assert.Len(t, report.MixedContent, tt.items)
+			findings := []models.Finding{}
+			for _, f := range report.Findings {
+				if strings.HasPrefix(f.Code, "mixed_content") {
+					findings = append(findings, f)
+				}
+			}
+			assert.Equal(t, tt.findings, findings)
+		})
+	}
+}
+
+func TestCrawl_MixedContent(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, err := w.Write([]byte(`<!DOCTYPE html><html><head><script src="http://cdn.example.com/app.js"></script></head><body><img src="/logo.png"></body></html>`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, newTestCrawler(Config{}).Crawl(result))
+
+	assert.Equal(t, []models.MixedContent{
+		{URL: "http://cdn.example.com/app.js", Kind: "script", Type: "active", Element: "script src"},
+	}, result.Security.MixedContent)
+}
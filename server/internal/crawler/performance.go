@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+const (
+	// maxProbedResources bounds the number of HEAD requests made to estimate the page weight.
+	maxProbedResources = 100
+	// probeConcurrency is the number of resource sizes probed at the same time.
+	probeConcurrency = 8
+)
+
+// inventoryKinds are the resource kinds listed in the performance inventory.
+var inventoryKinds = map[string]bool{
+	resourceScript: true, resourceStylesheet: true, resourceImage: true, resourceFont: true, resourceMedia: true,
+}
+
+// fetchTimer records the phases of an HTTP request through an httptrace.ClientTrace.
+type fetchTimer struct {
+	mu                        sync.Mutex
+	start                     time.Time
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	firstByte                 time.Time
+}
+
+func newFetchTimer() *fetchTimer {
+	return &fetchTimer{start: time.Now()}
+}
+
+// trace returns a ClientTrace recording into the timer. A phase can happen more than once,
+// e.g. when dialing several addresses or following redirects, so the first start and the last
+// completion are kept. The time to first byte is that of the final response.
+func (t *fetchTimer) trace() *httptrace.ClientTrace {
+	first := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if at.IsZero() {
+			*at = time.Now()
+		}
+	}
+	last := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*at = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { first(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { last(&t.dnsDone) },
+		ConnectStart:         func(string, string) { first(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { last(&t.connectDone) },
+		TLSHandshakeStart:    func() { first(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { last(&t.tlsDone) },
+		GotFirstResponseByte: func() { last(&t.firstByte) },
+	}
+}
+
+// record fills in the timings of a fetch that finished reading the body at end.
+func (t *fetchTimer) record(perf *models.Performance, end time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	perf.DNSLookupMs = milliseconds(t.dnsStart, t.dnsDone)
+	perf.ConnectMs = milliseconds(t.connectStart, t.connectDone)
+	perf.TLSHandshakeMs = milliseconds(t.tlsStart, t.tlsDone)
+	perf.TimeToFirstByteMs = milliseconds(t.start, t.firstByte)
+	perf.DownloadMs = milliseconds(t.firstByte, end)
+	perf.TotalMs = milliseconds(t.start, end)
+}
+
+// milliseconds returns the time between two instants in milliseconds, or 0 if either is unset.
+func milliseconds(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return 0
+	}
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+// compressionOf returns the content coding of a response, or "none".
+func compressionOf(resp *http.Response) string {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return "none"
+	}
+	return encoding
+}
+
+// inventoryResources lists the scripts, stylesheets, images, fonts and media of a page
+// and counts them by kind.
+func inventoryResources(resources []resource, perf *models.Performance) {
+	perf.Resources = []models.Resource{}
+	perf.ResourceCounts = map[string]int{}
+	seen := map[string]bool{}
+	for _, r := range resources {
+		if !inventoryKinds[r.Kind] || seen[r.URL] {
+			continue
+		}
+		seen[r.URL] = true
+		perf.Resources = append(perf.Resources, models.Resource{URL: r.URL, Kind: r.Kind})
+		perf.ResourceCounts[r.Kind]++
+	}
+}
+
+// probeResourceSizes reads the size of the inventoried resources with HEAD requests and adds
+// them to the page weight. Only the identity coding is accepted, so that the sizes are
+// uncompressed like the decoded size of the page; resources without a Content-Length, or
+// answered with a content coding anyway, are left unsized.
+func (c *Crawler) probeResourceSizes(perf *models.Performance) {
+	count := len(perf.Resources)
+	if count > maxProbedResources {
+		count = maxProbedResources
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, probeConcurrency)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(r *models.Resource) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			req, err := http.NewRequest(http.MethodHead, r.URL, nil)
+			if err != nil {
+				return
+			}
+			req.Header.Set("Accept-Encoding", "identity")
+			resp, err := c.linkClient.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode < 400 && resp.ContentLength > 0 && compressionOf(resp) == "none" {
+				r.Size = resp.ContentLength
+			}
+		}(&perf.Resources[i])
+	}
+	wg.Wait()
+
+	for _, r := range perf.Resources[:count] {
+		if r.Size > 0 {
+			perf.ProbedResources++
+			perf.PageWeight += r.Size
+		}
+	}
+}
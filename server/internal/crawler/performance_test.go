@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestCrawl_Performance(t *testing.T) {
+	page := []byte(`<!DOCTYPE html><html><head><title>Weighed</title>
+<link rel="stylesheet" href="/style.css"><script src="/app.js"></script>
+<link rel="preload" as="font" href="/font.woff2"></head>
+<body><img src="/logo.png"><img src="/missing.png"></body></html>`)
+	body := compress(t, "gzip", page)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/style.css":
+			w.Header().Set("Content-Length", "1000")
+		case "/app.js":
+			w.Header().Set("Content-Length", "5000")
+		case "/logo.png":
+			w.Header().Set("Content-Length", "200")
+		case "/font.woff2":
+			// Compressed although the probe only accepts the identity coding
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", "300")
+		case "/missing.png":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			_, _ = w.Write(body)
+		}
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, newTestCrawler(Config{}).Crawl(result))
+
+	perf := result.Performance
+	assert.Equal(t, "HTTP/1.1", perf.Protocol)
+	assert.Equal(t, "gzip", perf.Compression)
+	assert.Equal(t, int64(len(body)), perf.TransferSize)
+	assert.Equal(t, int64(len(page)), perf.DecodedSize)
+	assert.Greater(t, perf.ConnectMs, 0.0)
+	assert.Greater(t, perf.TimeToFirstByteMs, 0.0)
+	assert.GreaterOrEqual(t, perf.TotalMs, perf.TimeToFirstByteMs)
+	assert.Equal(t, map[string]int{"stylesheet": 1, "script": 1, "image": 2, "font": 1}, perf.ResourceCounts)
+	assert.Zero(t, perf.ProbedResources)
+	assert.Equal(t, int64(len(page)), perf.PageWeight)
+
+	// Probing adds the sizes of the resources to the page weight
+	result = &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int), Options: models.CrawlOptions{ProbeResources: true}}
+	require.NoError(t, newTestCrawler(Config{}).Crawl(result))
+
+	perf = result.Performance
+	assert.Equal(t, 3, perf.ProbedResources)
+	assert.Equal(t, int64(len(page))+6200, perf.PageWeight)
+	assert.Contains(t, perf.Resources, models.Resource{URL: ts.URL + "/app.js", Kind: "script", Size: 5000})
+	assert.Contains(t, perf.Resources, models.Resource{URL: ts.URL + "/missing.png", Kind: "image"})
+}
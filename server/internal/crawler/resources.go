@@ -0,0 +1,218 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Kinds of URLs referenced by a page other than navigation links.
+const (
+	resourceScript     = "script"
+	resourceStylesheet = "stylesheet"
+	resourceImage      = "image"
+	resourceFont       = "font"
+	resourceMedia      = "media"
+	resourceIframe     = "iframe"
+	resourceObject     = "object"
+	resourceCanonical  = "canonical"
+	resourceRefresh    = "refresh"
+	resourceForm       = "form"
+)
+
+var (
+	cssURLPattern      = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]+))\s*\)`)
+	cssImportPattern   = regexp.MustCompile(`@import\s+(?:"([^"]*)"|'([^']*)')`)
+	cssFontFacePattern = regexp.MustCompile(`(?is)@font-face\s*\{[^}]*\}`)
+	refreshURLPattern  = regexp.MustCompile(`(?i)^\s*\d*(?:\.\d*)?\s*[;,]?\s*(?:url\s*=\s*)?['"]?([^'"]+)`)
+)
+
+// preloadKinds maps the "as" attribute of preload links to resource kinds.
+var preloadKinds = map[string]string{
+	"script": resourceScript, "style": resourceStylesheet, "font": resourceFont,
+	"image": resourceImage, "audio": resourceMedia, "video": resourceMedia, "document": resourceIframe,
+}
+
+// resource is a URL referenced by a page, e.g. a script or an image.
+type resource struct {
+	URL  string
+	Kind string
+	Tag  string // element the URL was found on
+	Attr string // attribute the URL was found in, or "style" for CSS
+}
+
+// collectResources lists the URLs a page references other than its <a href> links: scripts,
+// stylesheets, images (including srcset candidates and CSS url() values), fonts, media, frames,
+// canonical and meta refresh targets, and form actions. URLs are resolved against base; only
+// http and https URLs are kept, and each URL is listed once per kind.
+func collectResources(doc *html.Node, base *url.URL) []resource {
+	var resources []resource
+	seen := map[string]bool{}
+	add := func(n *html.Node, attr, kind, ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+		parsed, err := url.Parse(ref)
+		if err != nil {
+			return
+		}
+		resolved := base.ResolveReference(parsed)
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			return
+		}
+		resolved.Fragment = ""
+		key := kind + " " + resolved.String()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		resources = append(resources, resource{URL: resolved.String(), Kind: kind, Tag: n.Data, Attr: attr})
+	}
+	addAttr := func(n *html.Node, attr, kind string) {
+		if hasAttr(n, attr) {
+			add(n, attr, kind, getAttr(n, attr))
+		}
+	}
+	addSrcset := func(n *html.Node, kind string) {
+		for _, candidate := range parseSrcset(getAttr(n, "srcset")) {
+			add(n, "srcset", kind, candidate)
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script":
+				addAttr(n, "src", resourceScript)
+			case "link":
+				for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+					switch rel {
+					case "stylesheet":
+						addAttr(n, "href", resourceStylesheet)
+					case "icon", "apple-touch-icon":
+						addAttr(n, "href", resourceImage)
+					case "canonical":
+						addAttr(n, "href", resourceCanonical)
+					case "preload", "modulepreload", "prefetch":
+						kind, ok := preloadKinds[strings.ToLower(getAttr(n, "as"))]
+						if rel == "modulepreload" {
+							kind, ok = resourceScript, true
+						}
+						if ok {
+							addAttr(n, "href", kind)
+						}
+					}
+				}
+			case "img":
+				addAttr(n, "src", resourceImage)
+				addSrcset(n, resourceImage)
+			case "source":
+				if n.Parent != nil && (n.Parent.Data == "video" || n.Parent.Data == "audio") {
+					addAttr(n, "src", resourceMedia)
+				} else {
+					addAttr(n, "src", resourceImage)
+					addSrcset(n, resourceImage)
+				}
+			case "video", "audio":
+				addAttr(n, "src", resourceMedia)
+				addAttr(n, "poster", resourceImage)
+			case "track":
+				addAttr(n, "src", resourceMedia)
+			case "iframe", "frame":
+				addAttr(n, "src", resourceIframe)
+			case "embed":
+				addAttr(n, "src", resourceObject)
+			case "object":
+				addAttr(n, "data", resourceObject)
+			case "input":
+				if strings.EqualFold(getAttr(n, "type"), "image") {
+					addAttr(n, "src", resourceImage)
+				}
+			case "form":
+				addAttr(n, "action", resourceForm)
+			case "meta":
+				if strings.EqualFold(getAttr(n, "http-equiv"), "refresh") {
+					if match := refreshURLPattern.FindStringSubmatch(getAttr(n, "content")); match != nil && strings.Contains(strings.ToLower(getAttr(n, "content")), "url") {
+						add(n, "content", resourceRefresh, match[1])
+					}
+				}
+			case "style":
+				for _, ref := range cssReferences(textContent(n)) {
+					add(n, "style", ref.kind, ref.url)
+				}
+			}
+			if style := getAttr(n, "style"); style != "" {
+				for _, ref := range cssReferences(style) {
+					add(n, "style", ref.kind, ref.url)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return resources
+}
+
+// parseSrcset returns the URLs of the candidates of a srcset attribute.
+func parseSrcset(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			urls = append(urls, fields[0])
+		}
+	}
+	return urls
+}
+
+type cssReference struct {
+	url  string
+	kind string
+}
+
+// cssReferences returns the URLs referenced by CSS: @import rules are stylesheets, url() values
+// inside @font-face rules are fonts, and any other url() value is taken to be an image.
+func cssReferences(css string) []cssReference {
+	var refs []cssReference
+	for _, match := range cssImportPattern.FindAllStringSubmatch(css, -1) {
+		refs = append(refs, cssReference{url: firstGroup(match), kind: resourceStylesheet})
+	}
+	fontFaces := cssFontFacePattern.FindAllStringIndex(css, -1)
+	for _, loc := range cssURLPattern.FindAllStringSubmatchIndex(css, -1) {
+		match := make([]string, 0, 4)
+		for i := 0; i < len(loc); i += 2 {
+			if loc[i] < 0 {
+				match = append(match, "")
+			} else {
+				match = append(match, css[loc[i]:loc[i+1]])
+			}
+		}
+		kind := resourceImage
+		if strings.HasSuffix(strings.TrimSpace(css[:loc[0]]), "@import") {
+			kind = resourceStylesheet
+		}
+		for _, face := range fontFaces {
+			if loc[0] >= face[0] && loc[1] <= face[1] {
+				kind = resourceFont
+			}
+		}
+		refs = append(refs, cssReference{url: firstGroup(match), kind: kind})
+	}
+	return refs
+}
+
+// firstGroup returns the first non-empty capture group of a regexp match.
+func firstGroup(match []string) string {
+	for _, group := range match[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
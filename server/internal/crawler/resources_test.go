@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestCollectResources(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<!DOCTYPE html>
+<html><head>
+<meta http-equiv="refresh" content="5; url=/next">
+<link rel="stylesheet" href="/main.css">
+<link rel="preload" href="/font.woff2" as="font">
+<link rel="icon" href="/favicon.ico">
+<link rel="canonical" href="https://example.com/page">
+<link rel="alternate" hreflang="de" href="/de/">
+<script src="/app.js"></script>
+<script>var inline = true;</script>
+<style>
+@import "/print.css";
+@font-face { font-family: Brand; src: url('/brand.woff2') format('woff2'); }
+body { background: url(/bg.png); }
+</style>
+</head><body>
+<img src="/hero.jpg" srcset="/hero-2x.jpg 2x, /hero-3x.jpg 3x">
+<img src="data:image/png;base64,AAAA">
+<picture><source srcset="/pic.webp"><img src="/pic.jpg"></picture>
+<video src="/clip.mp4" poster="/poster.jpg"><source src="/clip.webm"></video>
+<iframe src="http://widgets.example.net/embed"></iframe>
+<div style="background-image: url(&quot;/tile.png&quot;)"></div>
+<form action="/subscribe"></form>
+<a href="/not-a-resource">Link</a>
+<img src="/hero.jpg#again">
+</body></html>`))
+	require.NoError(t, err)
+
+	var got []string
+	for _, r := range collectResources(doc, mustParseURL(t, "https://example.com/page")) {
+		got = append(got, r.Kind+" "+strings.TrimPrefix(r.URL, "https://example.com"))
+	}
+
+	assert.Equal(t, []string{
+		"refresh /next",
+		"stylesheet /main.css",
+		"font /font.woff2",
+		"image /favicon.ico",
+		"canonical /page",
+		"script /app.js",
+		"stylesheet /print.css",
+		"font /brand.woff2",
+		"image /bg.png",
+		"image /hero.jpg",
+		"image /hero-2x.jpg",
+		"image /hero-3x.jpg",
+		"image /pic.webp",
+		"image /pic.jpg",
+		"media /clip.mp4",
+		"image /poster.jpg",
+		"media /clip.webm",
+		"iframe http://widgets.example.net/embed",
+		"image /tile.png",
+		"form /subscribe",
+	}, got)
+}
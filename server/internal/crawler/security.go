@@ -0,0 +1,362 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// HSTS max-age thresholds, in seconds.
+const (
+	minHSTSMaxAge     = 180 * 24 * 60 * 60 // 180 days
+	preloadHSTSMaxAge = 365 * 24 * 60 * 60 // 1 year, required for the preload list
+)
+
+// maxCookieDeduction caps the points deducted for cookie flags, so a page setting many
+// cookies is not graded worse than one missing a whole header.
+const maxCookieDeduction = 20
+
+// securityHeaders are the response headers evaluated by the security audit.
+var securityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"Content-Security-Policy-Report-Only",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// referrerPolicies are the valid values of the Referrer-Policy header.
+var referrerPolicies = map[string]bool{
+	"no-referrer": true, "no-referrer-when-downgrade": true, "origin": true, "origin-when-cross-origin": true,
+	"same-origin": true, "strict-origin": true, "strict-origin-when-cross-origin": true, "unsafe-url": true,
+}
+
+// securityGrades maps the minimum score of each grade, from best to worst.
+var securityGrades = []struct {
+	minScore int
+	grade    string
+}{
+	{90, "A"}, {80, "B"}, {70, "C"}, {60, "D"}, {0, "F"},
+}
+
+// securityAudit accumulates the report of the security audit, deducting points from a
+// perfect score for every finding.
+type securityAudit struct {
+	report          models.SecurityReport
+	cookieDeduction int
+}
+
+func (a *securityAudit) deduct(points int, code, severity, format string, args ...any) {
+	a.report.Score -= points
+	a.report.Findings = append(a.report.Findings, models.Finding{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// deductCookie deducts points for a cookie finding, up to maxCookieDeduction in total.
+func (a *securityAudit) deductCookie(points int, code, severity, format string, args ...any) {
+	points = min(points, maxCookieDeduction-a.cookieDeduction)
+	a.cookieDeduction += points
+	a.deduct(points, code, severity, format, args...)
+}
+
+// analyzeSecurity is the built-in "security" analyzer. It audits the transport and the security
+// headers and cookies of a response, of any content type, and records a graded score on the result.
+func analyzeSecurity(page *Page) (any, error) {
+	page.Result.Security = auditSecurity(page.Response, page.Doc, page.resources)
+	return nil, nil
+}
+
+// auditSecurity grades the security headers and cookies of a response and the resources of the
+// page it holds. doc, if not nil, is searched for a Content-Security-Policy declared in a <meta>
+// element.
+func auditSecurity(resp *http.Response, doc *html.Node, resources []resource) models.SecurityReport {
+	a := &securityAudit{report: models.SecurityReport{
+		Score:    100,
+		HTTPS:    resp.Request != nil && resp.Request.URL.Scheme == "https",
+		Headers:  map[string]string{},
+		Cookies:  []models.CookieReport{},
+		Findings: models.FindingList{},
+	}}
+	for _, name := range securityHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			a.report.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	a.checkTransport(resp.Header.Get("Strict-Transport-Security"))
+	a.checkCSP(resp.Header.Values("Content-Security-Policy"), metaCSP(doc), resp.Header.Get("Content-Security-Policy-Report-Only") != "")
+	a.checkFraming(resp.Header.Get("X-Frame-Options"))
+	a.checkContentTypeOptions(resp.Header.Get("X-Content-Type-Options"))
+	a.checkReferrerPolicy(resp.Header.Values("Referrer-Policy"))
+	if resp.Header.Get("Permissions-Policy") == "" {
+		a.deduct(5, "permissions_policy_missing", models.SeverityInfo, "Permissions-Policy is not set, so browser features such as the camera or geolocation are not restricted")
+	}
+	for _, cookie := range resp.Cookies() {
+		a.checkCookie(cookie)
+	}
+	a.checkMixedContent(resources)
+
+	a.report.Score = max(a.report.Score, 0)
+	for _, g := range securityGrades {
+		if a.report.Score >= g.minScore {
+			a.report.Grade = g.grade
+			break
+		}
+	}
+	return a.report
+}
+
+// checkTransport checks that the page is served over HTTPS with a Strict-Transport-Security policy.
+func (a *securityAudit) checkTransport(hsts string) {
+	if !a.report.HTTPS {
+		a.deduct(30, "not_https", models.SeverityError, "The page is not served over HTTPS")
+		if hsts != "" {
+			a.deduct(0, "hsts_over_http", models.SeverityInfo, "Strict-Transport-Security is ignored by browsers on plain HTTP responses")
+		}
+		return
+	}
+	if hsts == "" {
+		a.deduct(20, "hsts_missing", models.SeverityWarning, "Strict-Transport-Security is not set, so browsers may still connect over plain HTTP")
+		return
+	}
+
+	maxAge := -1
+	var includeSubDomains, preload bool
+	for _, directive := range strings.Split(hsts, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(value), `"`)); err == nil && n >= 0 {
+				maxAge = n
+			}
+		case "includesubdomains":
+			includeSubDomains = true
+		case "preload":
+			preload = true
+		}
+	}
+
+	switch {
+	case maxAge < 0:
+		a.deduct(20, "hsts_invalid", models.SeverityError, "Strict-Transport-Security has no valid max-age: %q", hsts)
+		return
+	case maxAge == 0:
+		a.deduct(20, "hsts_disabled", models.SeverityWarning, "Strict-Transport-Security has max-age=0, which disables it")
+		return
+	case maxAge < minHSTSMaxAge:
+		a.deduct(10, "hsts_short_max_age", models.SeverityWarning, "Strict-Transport-Security max-age is %d seconds; at least %d (180 days) is recommended", maxAge, minHSTSMaxAge)
+	}
+	if preload && (!includeSubDomains || maxAge < preloadHSTSMaxAge) {
+		a.deduct(0, "hsts_preload_ineligible", models.SeverityInfo, "Strict-Transport-Security requests preloading but the preload list requires includeSubDomains and a max-age of at least one year")
+	}
+}
+
+// checkCSP checks that a Content-Security-Policy restricts scripts without re-enabling inline
+// scripts or eval. A header may carry several comma-separated policies, and policies from headers
+// and <meta> elements are all enforced, each on its own: a script runs only if every policy allows
+// it, so a weakness is reported only if no policy restricting scripts rules it out. A report-only
+// policy is not enforced.
+func (a *securityAudit) checkCSP(headerValues []string, metaPolicies []string, reportOnly bool) {
+	policies := []map[string][]string{}
+	for _, value := range headerValues {
+		for _, policy := range strings.Split(value, ",") {
+			if csp := parseCSP(policy); len(csp) > 0 {
+				policies = append(policies, csp)
+			}
+		}
+	}
+	for _, policy := range metaPolicies {
+		csp := parseCSP(policy)
+		delete(csp, "frame-ancestors") // ignored in <meta> policies
+		if len(csp) > 0 {
+			policies = append(policies, csp)
+		}
+	}
+	a.report.CSP = policies
+
+	if len(policies) == 0 {
+		if reportOnly {
+			a.deduct(20, "csp_report_only", models.SeverityWarning, "Content-Security-Policy is only set in report-only mode, so it is not enforced")
+		} else {
+			a.deduct(20, "csp_missing", models.SeverityWarning, "Content-Security-Policy is not set")
+		}
+		return
+	}
+
+	restricted := false
+	unsafeInline, unsafeEval, wildcard := true, true, true
+	for _, csp := range policies {
+		scriptSources, ok := csp["script-src"]
+		if !ok {
+			scriptSources, ok = csp["default-src"]
+		}
+		if !ok {
+			continue
+		}
+		restricted = true
+		inline, eval, anyHost := scriptSourceWeaknesses(scriptSources)
+		unsafeInline = unsafeInline && inline
+		unsafeEval = unsafeEval && eval
+		wildcard = wildcard && anyHost
+	}
+	if !restricted {
+		a.deduct(10, "csp_no_script_restriction", models.SeverityWarning, "Content-Security-Policy has neither script-src nor default-src, so scripts are not restricted")
+		return
+	}
+	if unsafeInline {
+		a.deduct(10, "csp_unsafe_inline", models.SeverityWarning, "Content-Security-Policy allows inline scripts with 'unsafe-inline'")
+	}
+	if unsafeEval {
+		a.deduct(10, "csp_unsafe_eval", models.SeverityWarning, "Content-Security-Policy allows eval() with 'unsafe-eval'")
+	}
+	if wildcard {
+		a.deduct(5, "csp_wildcard_source", models.SeverityWarning, "Content-Security-Policy allows scripts from any host")
+	}
+}
+
+// scriptSourceWeaknesses reports whether a script source list allows inline scripts, eval() and
+// scripts from any host.
+func scriptSourceWeaknesses(sources []string) (unsafeInline, unsafeEval, wildcard bool) {
+	nonceOrHash := false
+	for _, source := range sources {
+		switch source = strings.ToLower(source); {
+		case source == "'unsafe-inline'":
+			unsafeInline = true
+		case source == "'unsafe-eval'":
+			unsafeEval = true
+		case strings.HasPrefix(source, "'nonce-"), strings.HasPrefix(source, "'sha"), source == "'strict-dynamic'":
+			nonceOrHash = true
+		case source == "*", source == "http:", source == "https:", source == "data:":
+			wildcard = true
+		}
+	}
+	// Browsers ignore 'unsafe-inline' when a nonce, hash or 'strict-dynamic' is present
+	return unsafeInline && !nonceOrHash, unsafeEval, wildcard
+}
+
+// cspHas reports whether any enforced policy has the named directive.
+func (a *securityAudit) cspHas(directive string) bool {
+	for _, csp := range a.report.CSP {
+		if _, ok := csp[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFraming checks that the page cannot be framed by other sites, with a CSP frame-ancestors
+// directive or X-Frame-Options.
+func (a *securityAudit) checkFraming(xfo string) {
+	if a.cspHas("frame-ancestors") {
+		return
+	}
+	switch value := strings.ToUpper(strings.TrimSpace(xfo)); {
+	case value == "DENY", value == "SAMEORIGIN":
+	case value == "":
+		a.deduct(15, "clickjacking_unprotected", models.SeverityWarning, "Neither X-Frame-Options nor a frame-ancestors directive prevents other sites from framing the page")
+	case strings.HasPrefix(value, "ALLOW-FROM"):
+		a.deduct(15, "xfo_invalid", models.SeverityWarning, "X-Frame-Options ALLOW-FROM is not supported by current browsers; use frame-ancestors instead")
+	default:
+		a.deduct(15, "xfo_invalid", models.SeverityWarning, "X-Frame-Options has the invalid value %q", xfo)
+	}
+}
+
+// checkContentTypeOptions checks that MIME type sniffing is disabled.
+func (a *securityAudit) checkContentTypeOptions(xcto string) {
+	switch value := strings.TrimSpace(xcto); {
+	case strings.EqualFold(value, "nosniff"):
+	case value == "":
+		a.deduct(10, "xcto_missing", models.SeverityWarning, "X-Content-Type-Options is not set to nosniff")
+	default:
+		a.deduct(10, "xcto_invalid", models.SeverityWarning, "X-Content-Type-Options has the invalid value %q; only nosniff is allowed", xcto)
+	}
+}
+
+// checkReferrerPolicy checks the Referrer-Policy. Browsers use the last valid policy of the list.
+func (a *securityAudit) checkReferrerPolicy(values []string) {
+	if len(values) == 0 {
+		a.deduct(5, "referrer_policy_missing", models.SeverityInfo, "Referrer-Policy is not set; browsers default to strict-origin-when-cross-origin")
+		return
+	}
+	var policy string
+	for _, value := range values {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.ToLower(strings.TrimSpace(token)); referrerPolicies[token] {
+				policy = token
+			}
+		}
+	}
+	switch policy {
+	case "":
+		a.deduct(5, "referrer_policy_invalid", models.SeverityWarning, "Referrer-Policy has no valid policy: %q", strings.Join(values, ", "))
+	case "unsafe-url":
+		a.deduct(5, "referrer_policy_unsafe", models.SeverityWarning, "Referrer-Policy unsafe-url sends the full URL, including the path and query, to every site")
+	}
+}
+
+// checkCookie records the attributes of a cookie and checks its Secure, HttpOnly and SameSite flags.
+func (a *securityAudit) checkCookie(cookie *http.Cookie) {
+	report := models.CookieReport{Name: cookie.Name, Secure: cookie.Secure, HttpOnly: cookie.HttpOnly}
+	switch cookie.SameSite {
+	case http.SameSiteStrictMode:
+		report.SameSite = "Strict"
+	case http.SameSiteLaxMode:
+		report.SameSite = "Lax"
+	case http.SameSiteNoneMode:
+		report.SameSite = "None"
+	}
+	a.report.Cookies = append(a.report.Cookies, report)
+
+	if !cookie.Secure && a.report.HTTPS {
+		a.deductCookie(5, "cookie_not_secure", models.SeverityWarning, "Cookie %q is set without the Secure flag and may be sent over plain HTTP", cookie.Name)
+	}
+	if !cookie.HttpOnly {
+		a.deductCookie(2, "cookie_not_httponly", models.SeverityInfo, "Cookie %q is set without the HttpOnly flag and can be read by scripts", cookie.Name)
+	}
+	switch {
+	case report.SameSite == "None" && !cookie.Secure:
+		a.deductCookie(5, "cookie_samesite_none_insecure", models.SeverityError, "Cookie %q has SameSite=None without the Secure flag and is rejected by browsers", cookie.Name)
+	case report.SameSite == "":
+		a.deductCookie(2, "cookie_samesite_missing", models.SeverityInfo, "Cookie %q has no SameSite attribute", cookie.Name)
+	}
+}
+
+// parseCSP parses a Content-Security-Policy into its directives and their source lists.
+// Directive names are lowercased; only the first occurrence of a directive counts.
+func parseCSP(policy string) map[string][]string {
+	directives := map[string][]string{}
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := directives[name]; !ok {
+			directives[name] = append([]string{}, fields[1:]...)
+		}
+	}
+	return directives
+}
+
+// metaCSP returns the policies declared with <meta http-equiv="Content-Security-Policy">.
+func metaCSP(doc *html.Node) []string {
+	var policies []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(strings.TrimSpace(getAttr(n, "http-equiv")), "content-security-policy") {
+			policies = append(policies, getAttr(n, "content"))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if doc != nil {
+		walk(doc)
+	}
+	return policies
+}
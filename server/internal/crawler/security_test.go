@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestAuditSecurity(t *testing.T) {
+	hardened := map[string][]string{
+		"Strict-Transport-Security": {"max-age=63072000; includeSubDomains; preload"},
+		"Content-Security-Policy":   {"default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; frame-ancestors 'none'"},
+		"X-Content-Type-Options":    {"nosniff"},
+		"Referrer-Policy":           {"strict-origin-when-cross-origin"},
+		"Permissions-Policy":        {"camera=(), geolocation=()"},
+		"Set-Cookie":                {"session=abc; Secure; HttpOnly; SameSite=Lax"},
+	}
+	with := func(overrides map[string][]string) http.Header {
+		header := http.Header{}
+		for name, values := range hardened {
+			header[name] = values
+		}
+		for name, values := range overrides {
+			if values == nil {
+				header.Del(name)
+			} else {
+				header[name] = values
+			}
+		}
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		header  http.Header
+		meta    string
+		score   int
+		grade   string
+		codes   []string
+		cookies []models.CookieReport
+	}{
+		{
+			name:    "hardened",
+			header:  with(nil),
+			score:   100,
+			grade:   "A",
+			codes:   []string{},
+			cookies: []models.CookieReport{{Name: "session", Secure: true, HttpOnly: true, SameSite: "Lax"}},
+		},
+		{
+			name:   "nothing set",
+			header: http.Header{},
+			score:  25,
+			grade:  "F",
+			codes:  []string{"hsts_missing", "csp_missing", "clickjacking_unprotected", "xcto_missing", "referrer_policy_missing", "permissions_policy_missing"},
+		},
+		{
+			name:   "plain HTTP",
+			url:    "http://example.com/",
+			header: with(nil),
+			score:  70,
+			grade:  "C",
+			codes:  []string{"not_https", "hsts_over_http"},
+		},
+		{
+			name:   "short HSTS max-age with preload",
+			header: with(map[string][]string{"Strict-Transport-Security": {"max-age=3600; preload"}}),
+			score:  90,
+			codes:  []string{"hsts_short_max_age", "hsts_preload_ineligible"},
+		},
+		{
+			name:   "HSTS without max-age",
+			header: with(map[string][]string{"Strict-Transport-Security": {"includeSubDomains"}}),
+			score:  80,
+			codes:  []string{"hsts_invalid"},
+		},
+		{
+			name: "unsafe CSP",
+			header: with(map[string][]string{
+				"Content-Security-Policy": {"script-src * 'unsafe-inline' 'unsafe-eval'"},
+				"X-Frame-Options":         {"SAMEORIGIN"},
+			}),
+			score: 75,
+			codes: []string{"csp_unsafe_inline", "csp_unsafe_eval", "csp_wildcard_source"},
+		},
+		{
+			name: "weaknesses ruled out by another policy",
+			header: with(map[string][]string{
+				"Content-Security-Policy": {"script-src * 'unsafe-inline' 'unsafe-eval'", "default-src 'self'; frame-ancestors 'self'"},
+			}),
+			score: 100,
+			codes: []string{},
+		},
+		{
+			name: "comma-separated policies sharing a weakness",
+			header: with(map[string][]string{
+				"Content-Security-Policy": {"script-src 'self' 'unsafe-eval', default-src * 'unsafe-eval' 'unsafe-inline'; frame-ancestors 'none'"},
+				"X-Frame-Options":         nil,
+			}),
+			score: 90,
+			codes: []string{"csp_unsafe_eval"},
+		},
+		{
+			name: "CSP without script restriction",
+			header: with(map[string][]string{
+				"Content-Security-Policy": {"img-src 'self'"},
+				"X-Frame-Options":         {"ALLOW-FROM https://example.net"},
+			}),
+			score: 75,
+			codes: []string{"csp_no_script_restriction", "xfo_invalid"},
+		},
+		{
+			name: "report-only CSP",
+			header: with(map[string][]string{
+				"Content-Security-Policy":             nil,
+				"Content-Security-Policy-Report-Only": {"default-src 'self'"},
+				"X-Frame-Options":                     {"DENY"},
+			}),
+			score: 80,
+			codes: []string{"csp_report_only"},
+		},
+		{
+			name:   "CSP in meta element without frame-ancestors",
+			header: with(map[string][]string{"Content-Security-Policy": nil}),
+			meta:   `<meta http-equiv="Content-Security-Policy" content="default-src 'self'; frame-ancestors 'none'">`,
+			score:  85,
+			codes:  []string{"clickjacking_unprotected"},
+		},
+		{
+			name: "invalid nosniff and referrer policy",
+			header: with(map[string][]string{
+				"X-Content-Type-Options": {"sniff"},
+				"Referrer-Policy":        {"no-referrer, unsafe-url"},
+			}),
+			score: 85,
+			codes: []string{"xcto_invalid", "referrer_policy_unsafe"},
+		},
+		{
+			name: "insecure cookies",
+			header: with(map[string][]string{"Set-Cookie": {
+				"prefs=dark",
+				"tracking=1; SameSite=None",
+				"a=1", "b=2", "c=3",
+			}}),
+			score: 80,
+			codes: []string{
+				"cookie_not_secure", "cookie_not_httponly", "cookie_samesite_missing",
+				"cookie_not_secure", "cookie_not_httponly", "cookie_samesite_none_insecure",
+				"cookie_not_secure", "cookie_not_httponly", "cookie_samesite_missing",
+				"cookie_not_secure", "cookie_not_httponly", "cookie_samesite_missing",
+				"cookie_not_secure", "cookie_not_httponly", "cookie_samesite_missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.url == "" {
+				tt.url = "https://example.com/"
+			}
+			resp := &http.Response{Header: tt.header, Request: httptest.NewRequest(http.MethodGet, tt.url, nil)}
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tt.meta + "</head></html>"))
+			require.NoError(t, err)
+
+			report := auditSecurity(resp, doc, nil)
+
+			var codes []string
+			for _, f := range report.Findings {
+				codes = append(codes, f.Code)
+			}
+			if codes == nil {
+				codes = []string{}
+			}
+			assert.Equal(t, tt.codes, codes)
+			assert.Equal(t, tt.score, report.Score)
+			if tt.grade != "" {
+				assert.Equal(t, tt.grade, report.Grade)
+			}
+			if tt.cookies != nil {
+				assert.Equal(t, tt.cookies, report.Cookies)
+			}
+		})
+	}
+}
+
+func TestCrawl_Security(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'unsafe-eval'")
+		w.Header().Set("X-Frame-Options", "DENY")
+		_, err := w.Write([]byte(`<!DOCTYPE html><html><head><title>Secure</title></head><body></body></html>`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	c := newTestCrawler(Config{})
+	c.client = ts.Client()
+	result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+	require.NoError(t, c.Crawl(result))
+
+	security := result.Security
+	assert.True(t, security.HTTPS)
+	assert.Equal(t, 50, security.Score)
+	assert.Equal(t, "F", security.Grade)
+	assert.Equal(t, "DENY", security.Headers["X-Frame-Options"])
+	assert.Equal(t, []map[string][]string{{"default-src": {"'self'"}, "script-src": {"'self'", "'unsafe-eval'"}}}, security.CSP)
+}
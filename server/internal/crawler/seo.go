@@ -0,0 +1,233 @@
+package crawler
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Length limits used by the SEO audit, in characters.
+const (
+	minTitleLength       = 30
+	maxTitleLength       = 60
+	maxDescriptionLength = 160
+)
+
+// valuedRobotsDirectives are robots directives that take a value after a colon. Any other
+// "name: value" token in an X-Robots-Tag header is scoped to the user agent called name.
+var valuedRobotsDirectives = map[string]bool{
+	"max-snippet": true, "max-image-preview": true, "max-video-preview": true, "unavailable_after": true,
+}
+
+// seoTagCounts counts tags that should appear at most once, for the duplicate checks of the audit.
+type seoTagCounts struct {
+	descriptions int
+}
+
+// analyzeSEO is the built-in "seo" analyzer. It records the SEO metadata of the page and
+// the findings of the audit on the result.
+func analyzeSEO(page *Page) (any, error) {
+	var counts seoTagCounts
+	page.Result.XRobotsTag = strings.Join(page.Response.Header.Values("X-Robots-Tag"), ", ")
+	base := page.BaseURL
+	if base == nil {
+		base = page.URL
+	}
+	extractSEOMetadata(page.Doc, base, page.Result, &counts)
+	page.Result.SEOFindings = auditSEO(page.Result, page.URL, counts)
+	return nil, nil
+}
+
+// extractSEOMetadata records the meta tags, canonical and alternate links, and document language
+// of a page. Relative URLs are resolved against baseURL.
+func extractSEOMetadata(n *html.Node, baseURL *url.URL, result *models.CrawlResult, counts *seoTagCounts) {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "html":
+			result.Lang = strings.TrimSpace(getAttr(n, "lang"))
+		case "meta":
+			extractMetaTag(n, result, counts)
+		case "link":
+			rels := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+			href := strings.TrimSpace(getAttr(n, "href"))
+			for _, rel := range rels {
+				switch {
+				case rel == "canonical" && result.CanonicalURL == "":
+					result.CanonicalURL = resolveURL(baseURL, href)
+				case rel == "alternate" && getAttr(n, "hreflang") != "":
+					result.Hreflang = append(result.Hreflang, models.HreflangLink{
+						Lang: strings.TrimSpace(getAttr(n, "hreflang")),
+						Href: resolveURL(baseURL, href),
+					})
+				}
+			}
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		extractSEOMetadata(c, baseURL, result, counts)
+	}
+}
+
+// extractMetaTag records a single <meta> element.
+func extractMetaTag(n *html.Node, result *models.CrawlResult, counts *seoTagCounts) {
+	name := strings.ToLower(strings.TrimSpace(getAttr(n, "name")))
+	property := strings.ToLower(strings.TrimSpace(getAttr(n, "property")))
+	content := strings.TrimSpace(getAttr(n, "content"))
+
+	switch {
+	case getAttr(n, "charset") != "":
+		result.Charset = strings.TrimSpace(getAttr(n, "charset"))
+	case strings.EqualFold(getAttr(n, "http-equiv"), "content-type"):
+		if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+			result.Charset = params["charset"]
+		}
+	case name == "description":
+		counts.descriptions++
+		if result.MetaDescription == "" {
+			result.MetaDescription = content
+		}
+	case name == "robots":
+		result.MetaRobots = joinDirectives(result.MetaRobots, content)
+	case name == "viewport":
+		result.Viewport = content
+	case strings.HasPrefix(property, "og:"):
+		if result.OpenGraph == nil {
+			result.OpenGraph = make(map[string]string)
+		}
+		result.OpenGraph[property] = content
+	case strings.HasPrefix(name, "twitter:") || strings.HasPrefix(property, "twitter:"):
+		if result.TwitterCard == nil {
+			result.TwitterCard = make(map[string]string)
+		}
+		if name == "" {
+			name = property
+		}
+		result.TwitterCard[name] = content
+	}
+}
+
+// auditSEO checks the extracted metadata of a page and reports problems as findings.
+func auditSEO(result *models.CrawlResult, pageURL *url.URL, counts seoTagCounts) models.FindingList {
+	findings := models.FindingList{}
+	add := func(code, severity, format string, args ...any) {
+		findings = append(findings, models.Finding{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	title := strings.TrimSpace(result.PageTitle)
+	switch titleLength := utf8.RuneCountInString(title); {
+	case titleLength == 0:
+		add("title_missing", models.SeverityError, "The page has no title")
+	case titleLength < minTitleLength:
+		add("title_too_short", models.SeverityWarning, "The title is %d characters long, shorter than the recommended %d", titleLength, minTitleLength)
+	case titleLength > maxTitleLength:
+		add("title_too_long", models.SeverityWarning, "The title is %d characters long and may be truncated in search results after %d", titleLength, maxTitleLength)
+	}
+
+	switch {
+	case counts.descriptions == 0:
+		add("description_missing", models.SeverityWarning, "The page has no meta description")
+	case counts.descriptions > 1:
+		add("description_duplicate", models.SeverityWarning, "The page has %d meta descriptions; only one should be present", counts.descriptions)
+	}
+	if descriptionLength := utf8.RuneCountInString(result.MetaDescription); descriptionLength > maxDescriptionLength {
+		add("description_too_long", models.SeverityInfo, "The meta description is %d characters long and may be truncated after %d", descriptionLength, maxDescriptionLength)
+	}
+
+	if result.CanonicalURL != "" {
+		canonical, err := url.Parse(result.CanonicalURL)
+		if err != nil || !canonical.IsAbs() {
+			add("canonical_invalid", models.SeverityWarning, "The canonical link %q is not a valid absolute URL", result.CanonicalURL)
+		} else if !sameDocument(canonical, pageURL) {
+			add("canonical_elsewhere", models.SeverityWarning, "The canonical link points to %s instead of this page", result.CanonicalURL)
+		}
+	}
+
+	meta := robotsDirectives(result.MetaRobots)
+	header := robotsDirectives(result.XRobotsTag)
+	if meta["noindex"] || header["noindex"] {
+		add("noindex", models.SeverityWarning, "The page asks search engines not to index it")
+	}
+	for _, pair := range [][2]string{{"index", "noindex"}, {"follow", "nofollow"}} {
+		allow, deny := pair[0], pair[1]
+		if meta[allow] && meta[deny] {
+			add("robots_contradiction", models.SeverityWarning, "The robots meta tags give contradictory %s/%s directives", allow, deny)
+		}
+		if header[allow] && header[deny] {
+			add("robots_contradiction", models.SeverityWarning, "The X-Robots-Tag header gives contradictory %s/%s directives", allow, deny)
+		}
+		if (meta[allow] && header[deny]) || (header[allow] && meta[deny]) {
+			add("robots_conflict", models.SeverityWarning, "The robots meta tag and X-Robots-Tag header give conflicting %s/%s directives", allow, deny)
+		}
+	}
+
+	if result.Viewport == "" {
+		add("viewport_missing", models.SeverityInfo, "The page has no viewport meta tag and may not render well on mobile devices")
+	}
+	if result.Lang == "" {
+		add("lang_missing", models.SeverityInfo, "The html element has no lang attribute")
+	}
+
+	return findings
+}
+
+// robotsDirectives parses a robots meta tag or X-Robots-Tag value into its lowercase directives.
+// "none" and "all" are expanded, and user-agent prefixes such as "googlebot: noindex" are dropped.
+func robotsDirectives(value string) map[string]bool {
+	directives := make(map[string]bool)
+	for _, token := range strings.Split(strings.ToLower(value), ",") {
+		token = strings.TrimSpace(token)
+		if name, rest, found := strings.Cut(token, ":"); found && !valuedRobotsDirectives[strings.TrimSpace(name)] {
+			token = strings.TrimSpace(rest)
+		}
+		switch token {
+		case "":
+		case "none":
+			directives["noindex"], directives["nofollow"] = true, true
+		case "all":
+			directives["index"], directives["follow"] = true, true
+		default:
+			directives[token] = true
+		}
+	}
+	return directives
+}
+
+// joinDirectives combines robots directives from repeated tags or headers.
+func joinDirectives(existing, value string) string {
+	switch {
+	case value == "":
+		return existing
+	case existing == "":
+		return value
+	default:
+		return existing + ", " + value
+	}
+}
+
+// resolveURL resolves href against base, returning href unchanged if it cannot be parsed.
+func resolveURL(base *url.URL, href string) string {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// sameDocument reports whether two URLs refer to the same document, ignoring the fragment,
+// the case of scheme and host, and an empty versus "/" path.
+func sameDocument(a, b *url.URL) bool {
+	normalize := func(u *url.URL) string {
+		path := u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+		return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path + "?" + u.RawQuery
+	}
+	return normalize(a) == normalize(b)
+}
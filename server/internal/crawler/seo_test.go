@@ -0,0 +1,157 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func TestCrawl_SEOMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Robots-Tag", "googlebot: noindex")
+		_, err := w.Write([]byte(`<!DOCTYPE html>
+<html lang="en-GB">
+<head>
+<meta charset="utf-8">
+<title>A title that is exactly long enough</title>
+<meta name="description" content="First description">
+<meta name="Description" content="Second description">
+<meta name="robots" content="index, follow">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta property="og:title" content="OG title">
+<meta property="og:image" content="/og.png">
+<meta name="twitter:card" content="summary">
+<link rel="canonical" href="/other-page">
+<link rel="alternate" hreflang="de" href="/de/">
+</head>
+<body></body>
+</html>`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	result := &models.CrawlResult{
+		URL:       ts.URL,
+		Status:    "queued",
+		Headings:  make(map[string]int),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := Crawl(result)
+	require.NoError(t, err)
+
+	assert.Equal(t, "First description", result.MetaDescription)
+	assert.Equal(t, "index, follow", result.MetaRobots)
+	assert.Equal(t, "googlebot: noindex", result.XRobotsTag)
+	assert.Equal(t, ts.URL+"/other-page", result.CanonicalURL)
+	assert.Equal(t, models.HreflangList{{Lang: "de", Href: ts.URL + "/de/"}}, result.Hreflang)
+	assert.Equal(t, models.StringMap{"og:title": "OG title", "og:image": "/og.png"}, result.OpenGraph)
+	assert.Equal(t, models.StringMap{"twitter:card": "summary"}, result.TwitterCard)
+	assert.Equal(t, "width=device-width, initial-scale=1", result.Viewport)
+	assert.Equal(t, "utf-8", result.Charset)
+	assert.Equal(t, "en-GB", result.Lang)
+
+	assert.ElementsMatch(t, []string{"description_duplicate", "canonical_elsewhere", "noindex", "robots_conflict"}, findingCodes(result.SEOFindings))
+}
+
+func TestAuditSEO(t *testing.T) {
+	pageURL := mustParseURL(t, "https://example.com/page")
+
+	tests := []struct {
+		name     string
+		result   models.CrawlResult
+		counts   seoTagCounts
+		expected []string
+	}{
+		{
+			name:     "Nothing set",
+			result:   models.CrawlResult{},
+			expected: []string{"title_missing", "description_missing", "viewport_missing", "lang_missing"},
+		},
+		{
+			name: "Short title, long description, self canonical",
+			result: models.CrawlResult{
+				PageTitle:       "Home",
+				MetaDescription: string(make([]byte, maxDescriptionLength+1)),
+				CanonicalURL:    "https://EXAMPLE.com/page#top",
+				Viewport:        "width=device-width",
+				Lang:            "en",
+			},
+			counts:   seoTagCounts{descriptions: 1},
+			expected: []string{"title_too_short", "description_too_long"},
+		},
+		{
+			name: "Long title and meta none",
+			result: models.CrawlResult{
+				PageTitle:       "A very long title that goes on and on well past the sixty character mark",
+				MetaDescription: "Description",
+				MetaRobots:      "none",
+				Viewport:        "width=device-width",
+				Lang:            "en",
+			},
+			counts:   seoTagCounts{descriptions: 1},
+			expected: []string{"title_too_long", "noindex"},
+		},
+		{
+			name: "Header allows what meta forbids",
+			result: models.CrawlResult{
+				PageTitle:       "A title that is exactly long enough",
+				MetaDescription: "Description",
+				MetaRobots:      "nofollow",
+				XRobotsTag:      "follow, max-snippet: 50",
+				Viewport:        "width=device-width",
+				Lang:            "en",
+			},
+			counts:   seoTagCounts{descriptions: 1},
+			expected: []string{"robots_conflict"},
+		},
+		{
+			name: "Meta tag contradicts itself",
+			result: models.CrawlResult{
+				PageTitle:       "A title that is exactly long enough",
+				MetaDescription: "Description",
+				MetaRobots:      "index, noindex",
+				Viewport:        "width=device-width",
+				Lang:            "en",
+			},
+			counts:   seoTagCounts{descriptions: 1},
+			expected: []string{"noindex", "robots_contradiction"},
+		},
+		{
+			name: "Header contradicts itself and the meta tag",
+			result: models.CrawlResult{
+				PageTitle:       "A title that is exactly long enough",
+				MetaDescription: "Description",
+				MetaRobots:      "follow",
+				XRobotsTag:      "follow, nofollow",
+				Viewport:        "width=device-width",
+				Lang:            "en",
+			},
+			counts:   seoTagCounts{descriptions: 1},
+			expected: []string{"robots_contradiction", "robots_conflict"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := auditSEO(&tt.result, pageURL, tt.counts)
+			assert.ElementsMatch(t, tt.expected, findingCodes(findings))
+		})
+	}
+}
+
+func findingCodes(findings models.FindingList) []string {
+	codes := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		codes = append(codes, finding.Code)
+	}
+	return codes
+}
@@ -0,0 +1,474 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Structured data formats.
+const (
+	formatJSONLD    = "json-ld"
+	formatMicrodata = "microdata"
+	formatRDFa      = "rdfa"
+)
+
+// typeAliases maps schema.org subtypes to the supported type they are validated as.
+var typeAliases = map[string]string{
+	"NewsArticle": "Article", "BlogPosting": "Article", "TechArticle": "Article", "ScholarlyArticle": "Article",
+	"Corporation": "Organization", "LocalBusiness": "Organization", "OnlineStore": "Organization",
+}
+
+// requiredProperties lists the properties each supported type must have to be eligible for rich results.
+var requiredProperties = map[string][]string{
+	"Product":        {"name"},
+	"Article":        {"headline", "author", "datePublished", "image"},
+	"BreadcrumbList": {"itemListElement"},
+	"Organization":   {"name", "url"},
+	"FAQPage":        {"mainEntity"},
+}
+
+// htmlSyntax describes how items are marked up by an attribute-based syntax (Microdata or RDFa).
+type htmlSyntax struct {
+	format        string
+	isScope       func(n *html.Node) bool
+	isProperty    func(n *html.Node) bool
+	itemType      func(n *html.Node) string
+	propertyNames func(n *html.Node) []string
+	value         func(n *html.Node) string
+}
+
+var microdata = htmlSyntax{
+	format:     formatMicrodata,
+	isScope:    func(n *html.Node) bool { return hasAttr(n, "itemscope") },
+	isProperty: func(n *html.Node) bool { return hasAttr(n, "itemprop") },
+	itemType: func(n *html.Node) string {
+		return schemaName(firstField(getAttr(n, "itemtype")))
+	},
+	propertyNames: func(n *html.Node) []string { return strings.Fields(getAttr(n, "itemprop")) },
+	value:         microdataValue,
+}
+
+var rdfa = htmlSyntax{
+	format:     formatRDFa,
+	isScope:    func(n *html.Node) bool { return hasAttr(n, "typeof") },
+	isProperty: func(n *html.Node) bool { return hasAttr(n, "property") },
+	itemType: func(n *html.Node) string {
+		return schemaName(firstField(getAttr(n, "typeof")))
+	},
+	propertyNames: func(n *html.Node) []string { return strings.Fields(getAttr(n, "property")) },
+	value: func(n *html.Node) string {
+		for _, key := range []string{"content", "href", "src", "resource", "datetime"} {
+			if hasAttr(n, key) {
+				return strings.TrimSpace(getAttr(n, key))
+			}
+		}
+		return textContent(n)
+	},
+}
+
+// analyzeStructuredData is the built-in "structured_data" analyzer.
+func analyzeStructuredData(page *Page) (any, error) {
+	page.Result.StructuredData = extractStructuredData(page.Doc)
+	return nil, nil
+}
+
+// extractStructuredData collects the JSON-LD, Microdata and RDFa items of a page and validates them.
+func extractStructuredData(doc *html.Node) models.StructuredData {
+	data := models.StructuredData{Items: []models.StructuredDataItem{}, Findings: models.FindingList{}}
+	jsonLDBlocks := 0
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "script" && strings.EqualFold(strings.TrimSpace(getAttr(n, "type")), "application/ld+json"):
+				jsonLDBlocks++
+				parseJSONLD(rawText(n), jsonLDBlocks, &data)
+			case microdata.isScope(n) && !microdata.isProperty(n):
+				data.Items = append(data.Items, newStructuredDataItem(formatMicrodata, scopedProperties(n, microdata)))
+			case rdfa.isScope(n) && !rdfa.isProperty(n):
+				data.Items = append(data.Items, newStructuredDataItem(formatRDFa, scopedProperties(n, rdfa)))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, item := range data.Items {
+		if len(item.MissingProperties) > 0 {
+			data.Findings = append(data.Findings, models.Finding{
+				Code:     "structured_data_missing_property",
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("%s (%s) is missing required properties: %s", item.Type, item.Format, strings.Join(item.MissingProperties, ", ")),
+			})
+		}
+	}
+	return data
+}
+
+// parseJSONLD parses a JSON-LD script block, which may hold a single entity, an array of
+// entities or an @graph, and records a finding if it is not valid JSON.
+func parseJSONLD(text string, block int, data *models.StructuredData) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var raw any
+	err := decoder.Decode(&raw)
+	if err == nil && decoder.More() {
+		err = fmt.Errorf("unexpected data after the top-level value")
+	}
+	if err != nil {
+		data.Findings = append(data.Findings, models.Finding{
+			Code:     "json_ld_syntax_error",
+			Severity: models.SeverityError,
+			Message:  fmt.Sprintf("JSON-LD block %d is not valid JSON: %v", block, err),
+		})
+		return
+	}
+
+	var entities []any
+	if graph, ok := asObject(raw)["@graph"]; ok {
+		entities = asList(graph)
+	} else {
+		entities = asList(raw)
+	}
+	for _, entity := range entities {
+		if properties := asObject(entity); properties != nil {
+			data.Items = append(data.Items, newStructuredDataItem(formatJSONLD, properties))
+		}
+	}
+}
+
+// scopedProperties builds the property map of a Microdata or RDFa item. Properties of nested
+// items are nested maps; properties given more than once become lists.
+func scopedProperties(scope *html.Node, syntax htmlSyntax) map[string]any {
+	properties := map[string]any{}
+	if itemType := syntax.itemType(scope); itemType != "" {
+		properties["@type"] = itemType
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if names := syntax.propertyNames(c); len(names) > 0 {
+				var value any
+				if syntax.isScope(c) {
+					value = scopedProperties(c, syntax)
+				} else {
+					value = syntax.value(c)
+				}
+				for _, name := range names {
+					addProperty(properties, schemaName(name), value)
+				}
+			}
+			if !syntax.isScope(c) {
+				walk(c)
+			}
+		}
+	}
+	walk(scope)
+	return properties
+}
+
+// microdataValue returns the value of a Microdata property element as defined by the HTML spec.
+func microdataValue(n *html.Node) string {
+	switch n.Data {
+	case "meta":
+		return strings.TrimSpace(getAttr(n, "content"))
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return strings.TrimSpace(getAttr(n, "src"))
+	case "a", "area", "link":
+		return strings.TrimSpace(getAttr(n, "href"))
+	case "object":
+		return strings.TrimSpace(getAttr(n, "data"))
+	case "data", "meter":
+		return strings.TrimSpace(getAttr(n, "value"))
+	case "time":
+		if hasAttr(n, "datetime") {
+			return strings.TrimSpace(getAttr(n, "datetime"))
+		}
+	}
+	return textContent(n)
+}
+
+// newStructuredDataItem builds an item from its parsed properties, converting the supported
+// schema.org types into their typed form and checking their required properties.
+func newStructuredDataItem(format string, properties map[string]any) models.StructuredDataItem {
+	item := models.StructuredDataItem{
+		Format:     format,
+		Type:       schemaName(asString(properties["@type"])),
+		Properties: properties,
+	}
+
+	kind := item.Type
+	if alias, ok := typeAliases[kind]; ok {
+		kind = alias
+	}
+	for _, name := range requiredProperties[kind] {
+		if isEmptyValue(properties[name]) {
+			item.MissingProperties = append(item.MissingProperties, name)
+		}
+	}
+
+	switch kind {
+	case "Product":
+		item.Product = toProduct(properties, &item.MissingProperties)
+	case "Article":
+		item.Article = &models.Article{
+			Headline:      asString(properties["headline"]),
+			Author:        asStrings(properties["author"]),
+			DatePublished: asString(properties["datePublished"]),
+			DateModified:  asString(properties["dateModified"]),
+			Image:         asURLs(properties["image"]),
+		}
+	case "BreadcrumbList":
+		item.BreadcrumbList = toBreadcrumbList(properties, &item.MissingProperties)
+	case "Organization":
+		item.Organization = &models.Organization{
+			Name:   asString(properties["name"]),
+			URL:    asString(properties["url"]),
+			Logo:   firstString(asURLs(properties["logo"])),
+			SameAs: asStrings(properties["sameAs"]),
+		}
+	case "FAQPage":
+		item.FAQPage = toFAQPage(properties, &item.MissingProperties)
+	}
+	return item
+}
+
+// toProduct converts a Product, which also needs offers, a review or an aggregate rating.
+func toProduct(properties map[string]any, missing *[]string) *models.Product {
+	product := &models.Product{
+		Name:        asString(properties["name"]),
+		Description: asString(properties["description"]),
+		SKU:         asString(properties["sku"]),
+		Brand:       asString(properties["brand"]),
+		Image:       asURLs(properties["image"]),
+	}
+	for i, offer := range asList(properties["offers"]) {
+		o := asObject(offer)
+		product.Offers = append(product.Offers, models.Offer{
+			Price:         asString(o["price"]),
+			PriceCurrency: asString(o["priceCurrency"]),
+			Availability:  schemaName(asString(o["availability"])),
+			URL:           asString(o["url"]),
+		})
+		if isEmptyValue(o["price"]) && isEmptyValue(o["lowPrice"]) {
+			*missing = append(*missing, fmt.Sprintf("offers[%d].price", i))
+		}
+	}
+	if rating := asObject(properties["aggregateRating"]); rating != nil {
+		product.AggregateRating = &models.AggregateRating{
+			RatingValue: asString(rating["ratingValue"]),
+			ReviewCount: asString(rating["reviewCount"]),
+		}
+	}
+	if isEmptyValue(properties["offers"]) && isEmptyValue(properties["review"]) && isEmptyValue(properties["aggregateRating"]) {
+		*missing = append(*missing, "offers, review or aggregateRating")
+	}
+	return product
+}
+
+// toBreadcrumbList converts a BreadcrumbList, whose list items each need a position and a name.
+func toBreadcrumbList(properties map[string]any, missing *[]string) *models.BreadcrumbList {
+	list := &models.BreadcrumbList{Items: []models.BreadcrumbItem{}}
+	for i, element := range asList(properties["itemListElement"]) {
+		e := asObject(element)
+		position, _ := strconv.Atoi(asString(e["position"]))
+		name := asString(e["name"])
+		if name == "" {
+			name = asString(asObject(e["item"])["name"])
+		}
+		list.Items = append(list.Items, models.BreadcrumbItem{
+			Position: position,
+			Name:     name,
+			URL:      firstString(asURLs(e["item"])),
+		})
+		if isEmptyValue(e["position"]) {
+			*missing = append(*missing, fmt.Sprintf("itemListElement[%d].position", i))
+		}
+		if name == "" {
+			*missing = append(*missing, fmt.Sprintf("itemListElement[%d].name", i))
+		}
+	}
+	return list
+}
+
+// toFAQPage converts an FAQPage, whose questions each need a name and an accepted answer.
+func toFAQPage(properties map[string]any, missing *[]string) *models.FAQPage {
+	page := &models.FAQPage{Questions: []models.FAQ{}}
+	for i, entity := range asList(properties["mainEntity"]) {
+		q := asObject(entity)
+		answer := asObject(q["acceptedAnswer"])
+		faq := models.FAQ{Question: asString(q["name"]), Answer: asString(answer["text"])}
+		page.Questions = append(page.Questions, faq)
+		if faq.Question == "" {
+			*missing = append(*missing, fmt.Sprintf("mainEntity[%d].name", i))
+		}
+		if faq.Answer == "" {
+			*missing = append(*missing, fmt.Sprintf("mainEntity[%d].acceptedAnswer.text", i))
+		}
+	}
+	return page
+}
+
+// addProperty sets a property, turning it into a list when it is given more than once.
+func addProperty(properties map[string]any, name string, value any) {
+	existing, ok := properties[name]
+	if !ok {
+		properties[name] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		properties[name] = append(list, value)
+		return
+	}
+	properties[name] = []any{existing, value}
+}
+
+// schemaName strips a schema.org namespace from a type, property or enumeration value,
+// e.g. "https://schema.org/InStock" or "schema:name".
+func schemaName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndexAny(name, "/#"); i >= 0 && strings.Contains(name, "://") {
+		return name[i+1:]
+	}
+	if prefix, rest, found := strings.Cut(name, ":"); found && !strings.Contains(prefix, "/") {
+		return rest
+	}
+	return name
+}
+
+// asString returns a property value as text. Entities are represented by their name, URL or ID,
+// and lists by their first element.
+func asString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]any:
+		for _, key := range []string{"name", "url", "@id"} {
+			if s := asString(v[key]); s != "" {
+				return s
+			}
+		}
+	case []any:
+		if len(v) > 0 {
+			return asString(v[0])
+		}
+	}
+	return ""
+}
+
+// asStrings returns every element of a property value as text.
+func asStrings(v any) []string {
+	var values []string
+	for _, element := range asList(v) {
+		if s := asString(element); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// asURLs returns the URLs of a property value that holds URLs or ImageObjects.
+func asURLs(v any) []string {
+	var urls []string
+	for _, element := range asList(v) {
+		if object := asObject(element); object != nil {
+			for _, key := range []string{"url", "contentUrl", "@id"} {
+				if s := asString(object[key]); s != "" {
+					urls = append(urls, s)
+					break
+				}
+			}
+		} else if s := asString(element); s != "" {
+			urls = append(urls, s)
+		}
+	}
+	return urls
+}
+
+// asList returns a property value as a list; single values become a list of one.
+func asList(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// asObject returns a property value as an entity, or nil if it is not one.
+func asObject(v any) map[string]any {
+	object, _ := v.(map[string]any)
+	return object
+}
+
+// isEmptyValue reports whether a property is missing or has no content.
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// firstString returns the first element of a list, or an empty string.
+func firstString(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// firstField returns the first space-separated token of an attribute value.
+func firstField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// rawText returns the unprocessed text content of an element such as <script>.
+func rawText(n *html.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			buf.WriteString(c.Data)
+		}
+	}
+	return buf.String()
+}
+
+// hasAttr reports whether an element has the named attribute, whatever its value.
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
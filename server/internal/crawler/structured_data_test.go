@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func parseStructuredData(t *testing.T, body string) models.StructuredData {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(body))
+	require.NoError(t, err)
+	return extractStructuredData(doc)
+}
+
+func TestExtractStructuredData_JSONLD(t *testing.T) {
+	data := parseStructuredData(t, `<html><head>
+<script type="application/ld+json">
+{
+  "@context": "https://schema.org",
+  "@type": "Product",
+  "name": "Anvil",
+  "brand": {"@type": "Brand", "name": "Acme"},
+  "image": ["https://example.com/anvil.jpg"],
+  "offers": {"@type": "Offer", "price": 99.5, "priceCurrency": "USD", "availability": "https://schema.org/InStock"}
+}
+</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+  {"@type": "BreadcrumbList", "itemListElement": [
+    {"@type": "ListItem", "position": 1, "name": "Home", "item": "https://example.com/"},
+    {"@type": "ListItem", "position": 2, "item": {"@id": "https://example.com/tools", "name": "Tools"}}
+  ]},
+  {"@type": "NewsArticle", "headline": "Anvils are back"}
+]}
+</script>
+<script type="application/ld+json">{"@type": "Organization", "name": "Acme",</script>
+</head></html>`)
+
+	require.Len(t, data.Items, 3)
+
+	product := data.Items[0]
+	assert.Equal(t, "json-ld", product.Format)
+	assert.Equal(t, "Product", product.Type)
+	assert.Empty(t, product.MissingProperties)
+	assert.Equal(t, &models.Product{
+		Name:   "Anvil",
+		Brand:  "Acme",
+		Image:  []string{"https://example.com/anvil.jpg"},
+		Offers: []models.Offer{{Price: "99.5", PriceCurrency: "USD", Availability: "InStock"}},
+	}, product.Product)
+
+	breadcrumbs := data.Items[1]
+	assert.Equal(t, &models.BreadcrumbList{Items: []models.BreadcrumbItem{
+		{Position: 1, Name: "Home", URL: "https://example.com/"},
+		{Position: 2, Name: "Tools", URL: "https://example.com/tools"},
+	}}, breadcrumbs.BreadcrumbList)
+
+	article := data.Items[2]
+	assert.Equal(t, "NewsArticle", article.Type)
+	assert.Equal(t, "Anvils are back", article.Article.Headline)
+	assert.Equal(t, []string{"author", "datePublished", "image"}, article.MissingProperties)
+
+	assert.ElementsMatch(t, []string{"structured_data_missing_property", "json_ld_syntax_error"}, findingCodes(data.Findings))
+}
+
+func TestExtractStructuredData_Microdata(t *testing.T) {
+	data := parseStructuredData(t, `<html><body>
+<div itemscope itemtype="https://schema.org/FAQPage">
+  <div itemprop="mainEntity" itemscope itemtype="https://schema.org/Question">
+    <h3 itemprop="name">Do you ship abroad?</h3>
+    <div itemprop="acceptedAnswer" itemscope itemtype="https://schema.org/Answer">
+      <p itemprop="text">Yes, worldwide.</p>
+    </div>
+  </div>
+  <div itemprop="mainEntity" itemscope itemtype="https://schema.org/Question">
+    <h3 itemprop="name">Can I return items?</h3>
+  </div>
+</div>
+<div itemscope itemtype="https://schema.org/Organization">
+  <a itemprop="url" href="https://example.com/"><span itemprop="name">Acme</span></a>
+  <img itemprop="logo" src="https://example.com/logo.png">
+</div>
+</body></html>`)
+
+	require.Len(t, data.Items, 2)
+
+	faq := data.Items[0]
+	assert.Equal(t, "microdata", faq.Format)
+	assert.Equal(t, &models.FAQPage{Questions: []models.FAQ{
+		{Question: "Do you ship abroad?", Answer: "Yes, worldwide."},
+		{Question: "Can I return items?"},
+	}}, faq.FAQPage)
+	assert.Equal(t, []string{"mainEntity[1].acceptedAnswer.text"}, faq.MissingProperties)
+
+	organization := data.Items[1]
+	assert.Equal(t, &models.Organization{Name: "Acme", URL: "https://example.com/", Logo: "https://example.com/logo.png"}, organization.Organization)
+	assert.Empty(t, organization.MissingProperties)
+}
+
+func TestExtractStructuredData_RDFa(t *testing.T) {
+	data := parseStructuredData(t, `<html><body vocab="https://schema.org/">
+<div typeof="Product">
+  <span property="description">A sturdy anvil</span>
+  <div property="offers" typeof="Offer">
+    <meta property="priceCurrency" content="EUR">
+  </div>
+</div>
+</body></html>`)
+
+	require.Len(t, data.Items, 1)
+	product := data.Items[0]
+	assert.Equal(t, "rdfa", product.Format)
+	assert.Equal(t, "Product", product.Type)
+	assert.Equal(t, "A sturdy anvil", product.Product.Description)
+	assert.Equal(t, []string{"name", "offers[0].price"}, product.MissingProperties)
+}
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "Product", schemaName("https://schema.org/Product"))
+	assert.Equal(t, "InStock", schemaName("http://schema.org/InStock"))
+	assert.Equal(t, "name", schemaName("schema:name"))
+	assert.Equal(t, "Product", schemaName(" Product "))
+}
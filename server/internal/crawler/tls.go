@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+// minRSAKeySize is the smallest RSA key size considered secure, in bits.
+const minRSAKeySize = 2048
+
+// weakSignatureAlgorithms are certificate signature algorithms relying on broken hashes.
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA: true, x509.MD5WithRSA: true, x509.SHA1WithRSA: true, x509.DSAWithSHA1: true, x509.ECDSAWithSHA1: true,
+}
+
+// inspectTLS records the negotiated TLS version and cipher suite and the details of the leaf
+// certificate of a connection to host. The chain is verified against roots, or the system roots
+// if nil, at time now; verification errors are recorded rather than failing the crawl. A warning
+// is reported when the certificate expires within warningDays.
+func inspectTLS(state *tls.ConnectionState, host string, roots *x509.CertPool, warningDays int, now time.Time) models.TLSReport {
+	report := models.TLSReport{
+		Version:          tls.VersionName(state.Version),
+		CipherSuite:      tls.CipherSuiteName(state.CipherSuite),
+		ValidationErrors: []string{},
+		Findings:         models.FindingList{},
+	}
+	addFinding := func(code, severity, message string) {
+		report.Findings = append(report.Findings, models.Finding{Code: code, Severity: severity, Message: message})
+	}
+
+	if state.Version < tls.VersionTLS12 {
+		addFinding("tls_outdated_version", models.SeverityWarning, report.Version+" is deprecated; TLS 1.2 or later is recommended")
+	}
+	if len(state.PeerCertificates) == 0 {
+		return report
+	}
+
+	leaf := state.PeerCertificates[0]
+	report.Subject = leaf.Subject.String()
+	report.SANs = append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		report.SANs = append(report.SANs, ip.String())
+	}
+	report.Issuer = leaf.Issuer.String()
+	report.NotBefore = leaf.NotBefore
+	report.NotAfter = leaf.NotAfter
+	report.DaysUntilExpiry = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	report.KeyType, report.KeySize = publicKeyInfo(leaf.PublicKey)
+	report.SignatureAlgorithm = leaf.SignatureAlgorithm.String()
+
+	// Verify the chain and the host name separately, so both problems are reported
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now}); err != nil {
+		report.ValidationErrors = append(report.ValidationErrors, err.Error())
+	}
+	if err := leaf.VerifyHostname(host); err != nil {
+		report.ValidationErrors = append(report.ValidationErrors, err.Error())
+	}
+	report.Valid = len(report.ValidationErrors) == 0
+	for _, msg := range report.ValidationErrors {
+		addFinding("cert_invalid", models.SeverityError, msg)
+	}
+
+	switch {
+	case now.After(leaf.NotAfter):
+		addFinding("cert_expired", models.SeverityError, "The certificate expired on "+leaf.NotAfter.Format(time.DateOnly))
+	case now.Before(leaf.NotBefore):
+		addFinding("cert_not_yet_valid", models.SeverityError, "The certificate is not valid before "+leaf.NotBefore.Format(time.DateOnly))
+	case report.DaysUntilExpiry < warningDays:
+		addFinding("cert_expiring", models.SeverityWarning, "The certificate expires on "+leaf.NotAfter.Format(time.DateOnly))
+	}
+	if report.KeyType == "RSA" && report.KeySize < minRSAKeySize {
+		addFinding("cert_weak_key", models.SeverityWarning, "The certificate has a weak RSA key; at least 2048 bits are recommended")
+	}
+	if weakSignatureAlgorithms[leaf.SignatureAlgorithm] {
+		addFinding("cert_weak_signature", models.SeverityWarning, "The certificate is signed with the weak algorithm "+report.SignatureAlgorithm)
+	}
+	return report
+}
+
+// publicKeyInfo returns the type and size in bits of a certificate's public key.
+func publicKeyInfo(key any) (string, int) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return "unknown", 0
+	}
+}
@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+)
+
+func newTLSTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, err := w.Write([]byte(`<!DOCTYPE html><html><head><title>TLS</title></head><body></body></html>`))
+		assert.NoError(t, err)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestCrawl_TLS(t *testing.T) {
+	ts := newTLSTestServer(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+
+	t.Run("trusted certificate", func(t *testing.T) {
+		c := newTestCrawler(Config{})
+		c.roots = roots
+		result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+		require.NoError(t, c.Crawl(result))
+
+		report := result.TLS
+		assert.Equal(t, "TLS 1.3", report.Version)
+		assert.NotEmpty(t, report.CipherSuite)
+		assert.Equal(t, "O=Acme Co", report.Subject)
+		assert.Equal(t, []string{"example.com", "*.example.com", "127.0.0.1", "::1"}, report.SANs)
+		assert.Equal(t, ts.Certificate().NotAfter, report.NotAfter)
+		assert.Greater(t, report.DaysUntilExpiry, 365)
+		assert.Equal(t, "RSA", report.KeyType)
+		assert.Equal(t, 2048, report.KeySize)
+		assert.Equal(t, "SHA256-RSA", report.SignatureAlgorithm)
+		assert.True(t, report.Valid)
+		assert.Empty(t, report.ValidationErrors)
+		assert.Empty(t, report.Findings)
+	})
+
+	t.Run("untrusted certificate is recorded and the page still analyzed", func(t *testing.T) {
+		result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+		require.NoError(t, newTestCrawler(Config{}).Crawl(result))
+
+		assert.Equal(t, "completed", result.Status)
+		assert.Equal(t, "TLS", result.PageTitle)
+		assert.False(t, result.TLS.Valid)
+		require.Len(t, result.TLS.ValidationErrors, 1)
+		assert.Contains(t, result.TLS.ValidationErrors[0], "certificate signed by unknown authority")
+		assert.Equal(t, "cert_invalid", result.TLS.Findings[0].Code)
+	})
+
+	t.Run("only the page is fetched despite an untrusted certificate", func(t *testing.T) {
+		var subrequests []string
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				subrequests = append(subrequests, r.Method+" "+r.URL.Path)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			_, err := w.Write([]byte(`<!DOCTYPE html><html><body><img src="/logo.png"><a href="/about#team">Team</a></body></html>`))
+			assert.NoError(t, err)
+		}))
+		defer ts.Close()
+		// Keep the TLS handshake errors of the refused requests out of the test output
+		ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+		result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int), Options: models.CrawlOptions{ProbeResources: true}}
+		require.NoError(t, newTestCrawler(Config{LinkCacheTTL: -1}).Crawl(result))
+
+		assert.Equal(t, "completed", result.Status)
+		assert.Empty(t, subrequests)
+		assert.Zero(t, result.Performance.ProbedResources)
+	})
+
+	t.Run("expiry within the warning window", func(t *testing.T) {
+		days := int(time.Until(ts.Certificate().NotAfter).Hours()/24) + 1
+		c := newTestCrawler(Config{CertExpiryWarningDays: days})
+		c.roots = roots
+		result := &models.CrawlResult{URL: ts.URL, Headings: make(map[string]int)}
+		require.NoError(t, c.Crawl(result))
+
+		require.Len(t, result.TLS.Findings, 1)
+		assert.Equal(t, "cert_expiring", result.TLS.Findings[0].Code)
+		assert.Equal(t, models.SeverityWarning, result.TLS.Findings[0].Severity)
+	})
+}
+
+func TestInspectTLS(t *testing.T) {
+	ts := newTLSTestServer(t)
+	cert := ts.Certificate()
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	state := &tls.ConnectionState{Version: tls.VersionTLS11, CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA, PeerCertificates: []*x509.Certificate{cert}}
+
+	codes := func(report models.TLSReport) []string {
+		var codes []string
+		for _, f := range report.Findings {
+			codes = append(codes, f.Code)
+		}
+		return codes
+	}
+
+	report := inspectTLS(state, "example.com", roots, 30, cert.NotAfter.Add(24*time.Hour))
+	assert.Equal(t, "TLS 1.1", report.Version)
+	assert.Equal(t, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", report.CipherSuite)
+	assert.Equal(t, -1, report.DaysUntilExpiry)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []string{"tls_outdated_version", "cert_invalid", "cert_expired"}, codes(report))
+
+	report = inspectTLS(state, "other.example.org", roots, 30, cert.NotBefore.Add(-time.Hour))
+	assert.Equal(t, []string{"tls_outdated_version", "cert_invalid", "cert_invalid", "cert_not_yet_valid"}, codes(report))
+	assert.Contains(t, report.ValidationErrors[1], "other.example.org")
+
+	report = inspectTLS(&tls.ConnectionState{Version: tls.VersionTLS13}, "example.com", roots, 30, time.Now())
+	assert.Equal(t, "TLS 1.3", report.Version)
+	assert.Empty(t, report.Subject)
+	assert.Empty(t, report.Findings)
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ResultPage is a page of a result listing with opaque cursors to the neighbouring pages.
+// Next and Prev are empty when there is no such page.
+type ResultPage struct {
+	Results []*models.CrawlResult
+	Total   int64
+	Next    string
+	Prev    string
+}
+
+// cursor is the decoded form of a keyset pagination token. It records the sort order it was
+// issued for and the sort column values of the row to continue from.
+type cursor struct {
+	Sort     string            `json:"s"`
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+// encodeCursor builds the token for continuing after (or, backward, before) the given row.
+func encodeCursor(fields []SortField, row *models.CrawlResult, backward bool) string {
+	c := cursor{Sort: sortKey(fields), Backward: backward}
+	for _, field := range fields {
+		value := sortableColumns[field.Column](row)
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		raw, _ := json.Marshal(value)
+		c.Values = append(c.Values, raw)
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a token and converts its values back to the types of their columns.
+func decodeCursor(token string, fields []SortField) (values []any, backward bool, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	if c.Sort != sortKey(fields) || len(c.Values) != len(fields) {
+		return nil, false, fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	for i, field := range fields {
+		value, err := decodeCursorValue(c.Values[i], sortableColumns[field.Column](&models.CrawlResult{}))
+		if err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+		values = append(values, value)
+	}
+	return values, c.Backward, nil
+}
+
+// decodeCursorValue decodes a cursor value into the same type as the column's zero value.
+func decodeCursorValue(raw json.RawMessage, zero any) (any, error) {
+	switch zero.(type) {
+	case time.Time:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case string:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case bool:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	default:
+		var n int64
+		err := json.Unmarshal(raw, &n)
+		return n, err
+	}
+}
+
+// sortKey is the canonical form of a sort specification, e.g. "-updated_at,url,id".
+func sortKey(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Desc {
+			parts = append(parts, "-"+field.Column)
+		} else {
+			parts = append(parts, field.Column)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// applyKeyset restricts a query to the rows strictly after the cursor position in the given order.
+// For columns (a, b, id) this expands to (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?),
+// with the comparison flipped for descending columns.
+func applyKeyset(query *gorm.DB, fields []SortField, values []any) *gorm.DB {
+	var clauses []string
+	var args []any
+	for i, field := range fields {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fields[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if field.Desc {
+			op = "<"
+		}
+		parts = append(parts, field.Column+" "+op+" ?")
+		args = append(args, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return query.Where(strings.Join(clauses, " OR "), args...)
+}
+
+// reverseSort flips the direction of every sort field.
+func reverseSort(fields []SortField) []SortField {
+	reversed := make([]SortField, len(fields))
+	for i, field := range fields {
+		reversed[i] = SortField{Column: field.Column, Desc: !field.Desc}
+	}
+	return reversed
+}
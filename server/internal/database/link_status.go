@@ -0,0 +1,43 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetLinkStatus returns the cached status of a URL if it was checked at or after notBefore.
+func (d *DB) GetLinkStatus(url string, notBefore time.Time) (status int, checkedAt time.Time, found bool, err error) {
+	var cached models.LinkStatus
+	err = d.db.Where("url_hash = ? AND checked_at >= ?", linkHash(url), notBefore).First(&cached).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	return cached.StatusCode, cached.CheckedAt, true, nil
+}
+
+// SaveLinkStatus stores the status of a URL, replacing any earlier one.
+func (d *DB) SaveLinkStatus(url string, status int, checkedAt time.Time) error {
+	cached := &models.LinkStatus{URLHash: linkHash(url), URL: url, StatusCode: status, CheckedAt: checkedAt}
+	return d.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(cached).Error
+}
+
+// DeleteLinkStatuses removes the statuses checked before checkedBefore, returning how many there were.
+func (d *DB) DeleteLinkStatuses(checkedBefore time.Time) (int64, error) {
+	result := d.db.Where("checked_at < ?", checkedBefore).Delete(&models.LinkStatus{})
+	return result.RowsAffected, result.Error
+}
+
+// linkHash keys cached statuses, as URLs are too long for a MySQL primary key.
+func linkHash(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
@@ -8,11 +8,17 @@ import (
 	gorm_mysql "gorm.io/driver/mysql"
 	gorm_sqlite "gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// crawlResultTagsTable is the join table between crawl results and tags.
+const crawlResultTagsTable = "crawl_result_tags"
+
 // DB is the database connection pool.
 type DB struct {
 	db *gorm.DB
+	// fullTextMinLength is the shortest word the MySQL full-text indexes hold; zero means the default.
+	fullTextMinLength int
 }
 
 // NewDB creates a new DB instance with GORM.
@@ -32,12 +38,21 @@ func NewDB() (*DB, error) {
 	}
 
 	// AutoMigrate will create or update the table based on the model.
-	err = gormDB.AutoMigrate(&models.CrawlResult{})
+	err = gormDB.AutoMigrate(&models.CrawlResult{}, &models.Tag{}, &models.SearchDocument{}, &models.LinkStatus{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate database: %w", err)
 	}
 
-	return &DB{db: gormDB}, nil
+	// Full-text indexes are MySQL specific, so they are not part of the model.
+	if err := migrateSearchIndexes(gormDB); err != nil {
+		return nil, err
+	}
+
+	d := &DB{db: gormDB, fullTextMinLength: loadFullTextMinLength(gormDB)}
+	if err := d.backfillSearchDocuments(); err != nil {
+		return nil, fmt.Errorf("failed to backfill the search index: %w", err)
+	}
+	return d, nil
 }
 
 // NewDBForTest creates a new DB instance for testing with SQLite.
@@ -48,12 +63,16 @@ func NewDBForTest() (*DB, error) {
 	}
 
 	// AutoMigrate will create or update the table based on the model.
-	err = gormDB.AutoMigrate(&models.CrawlResult{})
+	err = gormDB.AutoMigrate(&models.CrawlResult{}, &models.Tag{}, &models.SearchDocument{}, &models.LinkStatus{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate database: %w", err)
 	}
 
-	return &DB{db: gormDB}, nil
+	d := &DB{db: gormDB}
+	if err := d.backfillSearchDocuments(); err != nil {
+		return nil, fmt.Errorf("failed to backfill the search index: %w", err)
+	}
+	return d, nil
 }
 
 // Close closes the database connection (not typically needed for GORM, but good practice).
@@ -69,75 +88,238 @@ func (d *DB) Close() error {
 }
 
 // CreateCrawlResult inserts a new CrawlResult into the database.
+// Tags are matched by name, so existing tags are reused rather than duplicated.
 func (d *DB) CreateCrawlResult(result *models.CrawlResult) error {
-	return d.db.Create(result).Error
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		tags, err := findOrCreateTags(tx, tagNames(result.Tags))
+		if err != nil {
+			return err
+		}
+		result.Tags = nil
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		result.Tags = tags
+		return tx.Model(result).Association("Tags").Replace(tags)
+	})
 }
 
 // GetCrawlResult retrieves a CrawlResult from the database by ID.
 func (d *DB) GetCrawlResult(id uint) (*models.CrawlResult, error) {
 	result := &models.CrawlResult{}
-	err := d.db.First(result, "id = ?", id).Error
+	err := d.db.Preload("Tags").First(result, "id = ?", id).Error
 	return result, err
 }
 
 // UpdateCrawlResult updates an existing CrawlResult in the database.
+// Tags are left untouched; use SetCrawlResultTags to change them.
 func (d *DB) UpdateCrawlResult(result *models.CrawlResult) error {
-	return d.db.Save(result).Error
+	return d.db.Omit(clause.Associations).Save(result).Error
+}
+
+// SetCrawlResultTags replaces the tags of a CrawlResult and returns the updated result.
+func (d *DB) SetCrawlResultTags(id uint, names []string) (*models.CrawlResult, error) {
+	result := &models.CrawlResult{}
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(result, "id = ?", id).Error; err != nil {
+			return err
+		}
+		tags, err := findOrCreateTags(tx, names)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return tx.Model(result).Association("Tags").Clear()
+		}
+		return tx.Model(result).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.GetCrawlResult(id)
+}
+
+// GetTagCounts returns every tag in use together with the number of results carrying it.
+func (d *DB) GetTagCounts() ([]models.TagCount, error) {
+	var counts []models.TagCount
+	err := d.db.Table("tags").
+		Select("tags.name AS name, COUNT(*) AS count").
+		Joins("JOIN " + crawlResultTagsTable + " ON " + crawlResultTagsTable + ".tag_id = tags.id").
+		Group("tags.name").
+		Order("tags.name").
+		Scan(&counts).Error
+	return counts, err
 }
 
 // DeleteCrawlResult deletes a CrawlResult from the database.
 func (d *DB) DeleteCrawlResult(id uint) error {
-	return d.db.Delete(&models.CrawlResult{}, "id = ?", id).Error
+	return d.DeleteCrawlResults([]uint{id})
 }
 
 // DeleteCrawlResults deletes multiple CrawlResults from the database.
 func (d *DB) DeleteCrawlResults(ids []uint) error {
-	return d.db.Delete(&models.CrawlResult{}, "id IN ?", ids).Error
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+crawlResultTagsTable+" WHERE crawl_result_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.SearchDocument{}, "crawl_result_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.CrawlResult{}, "id IN ?", ids).Error
+	})
 }
 
 // GetCrawlResults retrieves a paginated list of CrawlResults.
-func (d *DB) GetCrawlResults(limit, offset int, sortBy, filterBy string) ([]*models.CrawlResult, error) {
+func (d *DB) GetCrawlResults(q ResultQuery) ([]*models.CrawlResult, error) {
 	var results []*models.CrawlResult
-	query := d.db.Offset(offset).Limit(limit)
 
-	if sortBy != "" {
-		query = query.Order(sortBy)
-	}
+	query := d.filteredResults(q)
+	query = applySort(query, withTiebreaker(q.Sort))
+	query = applyPagination(query, q.Limit, q.Offset)
 
-	if filterBy != "" {
-		query = query.Where("url LIKE ?", "%"+filterBy+"%")
-	}
-
-	err := query.Find(&results).Error
+	err := query.Preload("Tags").Find(&results).Error
 	return results, err
 }
 
 // GetCrawlResultsAndTotal retrieves a paginated list of CrawlResults and their total count.
-func (d *DB) GetCrawlResultsAndTotal(limit, offset int, sortBy, filterBy string) ([]*models.CrawlResult, int64, error) {
-	var results []*models.CrawlResult
-	var total int64
+func (d *DB) GetCrawlResultsAndTotal(q ResultQuery) ([]*models.CrawlResult, int64, error) {
+	page, err := d.GetCrawlResultsPage(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	return page.Results, page.Total, nil
+}
 
-	query := d.db.Model(&models.CrawlResult{})
+// GetCrawlResultsPage retrieves a page of CrawlResults, its total count and cursors to the
+// neighbouring pages. Pages are addressed by q.Cursor when set, otherwise by q.Offset.
+func (d *DB) GetCrawlResultsPage(q ResultQuery) (*ResultPage, error) {
+	page := &ResultPage{}
+	sort := withTiebreaker(q.Sort)
 
-	if filterBy != "" {
-		query = query.Where("url LIKE ?", "%"+filterBy+"%")
-	}
+	query := d.filteredResults(q)
 
 	// Get total count first
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
 	}
 
-	// Apply pagination and sorting
-	query = query.Offset(offset).Limit(limit)
+	var values []any
+	backward := false
+	if q.Cursor != "" {
+		var err error
+		values, backward, err = decodeCursor(q.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if sortBy != "" {
-		query = query.Order(sortBy)
+	// Apply pagination and sorting. One extra row is fetched to tell whether another page follows.
+	order := sort
+	if backward {
+		order = reverseSort(sort)
+	}
+	if values != nil {
+		query = applyKeyset(query, order, values)
+	} else {
+		query = query.Offset(q.Offset)
+	}
+	query = applySort(query, order)
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit + 1)
 	}
 
-	if err := query.Find(&results).Error; err != nil {
-		return nil, 0, err
+	if err := query.Preload("Tags").Find(&page.Results).Error; err != nil {
+		return nil, err
+	}
+
+	hasMore := q.Limit > 0 && len(page.Results) > q.Limit
+	if hasMore {
+		page.Results = page.Results[:q.Limit]
+	}
+	if backward {
+		for i, j := 0, len(page.Results)-1; i < j; i, j = i+1, j-1 {
+			page.Results[i], page.Results[j] = page.Results[j], page.Results[i]
+		}
+	}
+	if len(page.Results) == 0 {
+		return page, nil
+	}
+
+	first, last := page.Results[0], page.Results[len(page.Results)-1]
+	if hasMore || backward {
+		page.Next = encodeCursor(sort, last, false)
+	}
+	if (backward && hasMore) || (!backward && (values != nil || q.Offset > 0)) {
+		page.Prev = encodeCursor(sort, first, true)
+	}
+
+	return page, nil
+}
+
+// filteredResults builds the CrawlResult query for the filters of q, without sorting or pagination.
+func (d *DB) filteredResults(q ResultQuery) *gorm.DB {
+	query := d.db.Model(&models.CrawlResult{})
+	query = applyFilter(query, q.Filter)
+	return d.applyTagFilter(query, q.Tags)
+}
+
+// applyPagination applies limit and offset; a non-positive limit means no limit.
+func applyPagination(query *gorm.DB, limit, offset int) *gorm.DB {
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	return query
+}
+
+// applyTagFilter narrows a CrawlResult query to results matching the tag filter.
+func (d *DB) applyTagFilter(query *gorm.DB, filter TagFilter) *gorm.DB {
+	if len(filter.Names) == 0 {
+		return query
+	}
+
+	matching := d.db.Table(crawlResultTagsTable).
+		Select(crawlResultTagsTable+".crawl_result_id").
+		Joins("JOIN tags ON tags.id = "+crawlResultTagsTable+".tag_id").
+		Where("tags.name IN ?", filter.Names)
+	if filter.MatchAll {
+		matching = matching.
+			Group(crawlResultTagsTable+".crawl_result_id").
+			Having("COUNT(DISTINCT tags.id) = ?", len(filter.Names))
 	}
 
-	return results, total, nil
+	return query.Where("id IN (?)", matching)
+}
+
+// findOrCreateTags returns the tags with the given names, creating any that do not exist yet.
+// Tags are inserted ignoring conflicts and then re-selected, so that concurrent requests creating
+// the same tag both succeed. The re-select is a locking read, which sees a tag committed by
+// another transaction after this one started.
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Tag{Name: name}).Error; err != nil {
+			return nil, fmt.Errorf("failed to create tag %q: %w", name, err)
+		}
+		tag := models.Tag{}
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("name = ?", name).First(&tag).Error; err != nil {
+			return nil, fmt.Errorf("failed to find tag %q: %w", name, err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// tagNames returns the names of the given tags.
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
 }
@@ -149,24 +149,24 @@ func TestGetCrawlResults(t *testing.T) {
 	}
 
 	// Test pagination
-	results, err := dbInstance.GetCrawlResults(2, 0, "", "")
+	results, err := dbInstance.GetCrawlResults(ResultQuery{Limit: 2})
 	assert.NoError(t, err)
 	assert.Len(t, results, 2)
 	assert.Equal(t, "Page 0", results[0].PageTitle)
 
-	results, err = dbInstance.GetCrawlResults(2, 2, "", "")
+	results, err = dbInstance.GetCrawlResults(ResultQuery{Limit: 2, Offset: 2})
 	assert.NoError(t, err)
 	assert.Len(t, results, 2)
 	assert.Equal(t, "Page 2", results[0].PageTitle)
 
 	// Test sorting
-	results, err = dbInstance.GetCrawlResults(5, 0, "page_title desc", "")
+	results, err = dbInstance.GetCrawlResults(ResultQuery{Limit: 5, Sort: mustParseSort(t, "page_title desc")})
 	assert.NoError(t, err)
 	assert.Len(t, results, 5)
 	assert.Equal(t, "Page 4", results[0].PageTitle)
 
 	// Test filtering
-	results, err = dbInstance.GetCrawlResults(5, 0, "", "page1")
+	results, err = dbInstance.GetCrawlResults(ResultQuery{Limit: 5, Filter: ResultFilter{URL: "page1"}})
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 	assert.Equal(t, "Page 1", results[0].PageTitle)
@@ -191,29 +191,402 @@ func TestGetCrawlResultsAndTotal(t *testing.T) {
 	}
 
 	// Test pagination and total count
-	results, total, err := dbInstance.GetCrawlResultsAndTotal(3, 0, "", "")
+	results, total, err := dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 3})
 	assert.NoError(t, err)
 	assert.Len(t, results, 3)
 	assert.Equal(t, int64(7), total)
 	assert.Equal(t, "Total Page 0", results[0].PageTitle)
 
-	results, total, err = dbInstance.GetCrawlResultsAndTotal(3, 3, "", "")
+	results, total, err = dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 3, Offset: 3})
 	assert.NoError(t, err)
 	assert.Len(t, results, 3)
 	assert.Equal(t, int64(7), total)
 	assert.Equal(t, "Total Page 3", results[0].PageTitle)
 
 	// Test filtering and total count
-	results, total, err = dbInstance.GetCrawlResultsAndTotal(5, 0, "", "total-page5")
+	results, total, err = dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 5, Filter: ResultFilter{URL: "total-page5"}})
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 	assert.Equal(t, int64(1), total)
 	assert.Equal(t, "Total Page 5", results[0].PageTitle)
 
 	// Test sorting, filtering and total count
-	results, total, err = dbInstance.GetCrawlResultsAndTotal(5, 0, "page_title desc", "total-page")
+	results, total, err = dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 5, Sort: mustParseSort(t, "page_title desc"), Filter: ResultFilter{URL: "total-page"}})
 	assert.NoError(t, err)
 	assert.Len(t, results, 5)
 	assert.Equal(t, int64(7), total)
 	assert.Equal(t, "Total Page 6", results[0].PageTitle)
 }
+
+func TestCrawlResultTags(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	acme := &models.CrawlResult{
+		URL:  "http://example.com/acme",
+		Tags: []models.Tag{{Name: "client:acme"}, {Name: "env:staging"}},
+	}
+	err = dbInstance.CreateCrawlResult(acme)
+	assert.NoError(t, err)
+
+	other := &models.CrawlResult{
+		URL:  "http://example.com/other",
+		Tags: []models.Tag{{Name: "client:other"}, {Name: "env:staging"}},
+	}
+	err = dbInstance.CreateCrawlResult(other)
+	assert.NoError(t, err)
+
+	// Existing tags are reused rather than duplicated
+	var tagCount int64
+	dbInstance.db.Model(&models.Tag{}).Count(&tagCount)
+	assert.Equal(t, int64(3), tagCount)
+
+	retrieved, err := dbInstance.GetCrawlResult(acme.ID)
+	assert.NoError(t, err)
+	assert.Len(t, retrieved.Tags, 2)
+
+	// OR semantics
+	results, total, err := dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 10, Tags: TagFilter{Names: []string{"client:acme", "client:other"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, results, 2)
+
+	// AND semantics
+	results, total, err = dbInstance.GetCrawlResultsAndTotal(ResultQuery{Limit: 10, Tags: TagFilter{Names: []string{"client:acme", "env:staging"}, MatchAll: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, acme.ID, results[0].ID)
+	assert.Len(t, results[0].Tags, 2)
+
+	// Replacing tags
+	updated, err := dbInstance.SetCrawlResultTags(acme.ID, []string{"campaign:q4"})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Tags, 1)
+	assert.Equal(t, "campaign:q4", updated.Tags[0].Name)
+
+	_, err = dbInstance.SetCrawlResultTags(uint(99999), []string{"campaign:q4"})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// Aggregation
+	counts, err := dbInstance.GetTagCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCount{
+		{Name: "campaign:q4", Count: 1},
+		{Name: "client:other", Count: 1},
+		{Name: "env:staging", Count: 1},
+	}, counts)
+
+	// Deleting a result removes its tag associations
+	err = dbInstance.DeleteCrawlResult(other.ID)
+	assert.NoError(t, err)
+	counts, err = dbInstance.GetTagCounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "campaign:q4", Count: 1}}, counts)
+}
+
+func TestParseSort(t *testing.T) {
+	fields, err := ParseSort("-updated_at, url")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortField{{Column: "updated_at", Desc: true}, {Column: "url"}}, fields)
+
+	fields, err = ParseSort("page_title desc")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortField{{Column: "page_title", Desc: true}}, fields)
+
+	_, err = ParseSort("id; DROP TABLE crawl_results")
+	assert.Error(t, err)
+
+	_, err = ParseSort("url,-url")
+	assert.Error(t, err)
+
+	_, err = ParseSort("url sideways")
+	assert.Error(t, err)
+}
+
+func TestGetCrawlResultsWithFilters(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fixtures := []*models.CrawlResult{
+		{URL: "http://example.com/a", Status: "completed", PageTitle: "Pricing", HTMLVersion: "HTML5", HasLoginForm: true, InaccessibleLinksCount: 0, CreatedAt: base},
+		{URL: "http://example.com/b", Status: "completed", PageTitle: "About us", HTMLVersion: "HTML5", InaccessibleLinksCount: 3, CreatedAt: base.Add(24 * time.Hour)},
+		{URL: "http://example.com/c", Status: "error", PageTitle: "Pricing plans", HTMLVersion: "HTML 4.01 Strict", InaccessibleLinksCount: 10, CreatedAt: base.Add(48 * time.Hour)},
+	}
+	for _, result := range fixtures {
+		err = dbInstance.CreateCrawlResult(result)
+		assert.NoError(t, err)
+	}
+
+	hasLoginForm := false
+	minBroken, maxBroken := 1, 5
+	after := base.Add(12 * time.Hour)
+
+	tests := []struct {
+		name     string
+		query    ResultQuery
+		expected []string
+	}{
+		{"status", ResultQuery{Filter: ResultFilter{Statuses: []string{"completed"}}}, []string{"http://example.com/a", "http://example.com/b"}},
+		{"html version", ResultQuery{Filter: ResultFilter{HTMLVersions: []string{"HTML 4.01 Strict"}}}, []string{"http://example.com/c"}},
+		{"login form", ResultQuery{Filter: ResultFilter{HasLoginForm: &hasLoginForm}}, []string{"http://example.com/b", "http://example.com/c"}},
+		{"broken links range", ResultQuery{Filter: ResultFilter{MinBrokenLinks: &minBroken, MaxBrokenLinks: &maxBroken}}, []string{"http://example.com/b"}},
+		{"created after", ResultQuery{Filter: ResultFilter{CreatedAfter: &after}}, []string{"http://example.com/b", "http://example.com/c"}},
+		{"title search", ResultQuery{Filter: ResultFilter{Title: "pricing"}}, []string{"http://example.com/a", "http://example.com/c"}},
+		{"multi-column sort", ResultQuery{Sort: mustParseSort(t, "status,-inaccessible_links_count")}, []string{"http://example.com/b", "http://example.com/a", "http://example.com/c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := dbInstance.GetCrawlResultsAndTotal(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(tt.expected)), total)
+			urls := make([]string, 0, len(results))
+			for _, result := range results {
+				urls = append(urls, result.URL)
+			}
+			assert.Equal(t, tt.expected, urls)
+		})
+	}
+}
+
+func TestGetCrawlResultsWithFilters_LiteralWildcards(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	for _, result := range []*models.CrawlResult{
+		{URL: "http://example.com/sale/100%", PageTitle: "100% off"},
+		{URL: "http://example.com/sale/1000", PageTitle: "1000 deals"},
+		{URL: "http://example.com/new_arrivals", PageTitle: `C:\deals`},
+		{URL: "http://example.com/newXarrivals", PageTitle: "C:deals"},
+	} {
+		assert.NoError(t, dbInstance.CreateCrawlResult(result))
+	}
+
+	tests := []struct {
+		name     string
+		filter   ResultFilter
+		expected []string
+	}{
+		{"percent", ResultFilter{URL: "100%"}, []string{"http://example.com/sale/100%"}},
+		{"underscore", ResultFilter{URL: "new_"}, []string{"http://example.com/new_arrivals"}},
+		{"backslash", ResultFilter{Title: `:\`}, []string{"http://example.com/new_arrivals"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := dbInstance.GetCrawlResults(ResultQuery{Filter: tt.filter, Sort: mustParseSort(t, "id")})
+			assert.NoError(t, err)
+			urls := make([]string, 0, len(results))
+			for _, result := range results {
+				urls = append(urls, result.URL)
+			}
+			assert.Equal(t, tt.expected, urls)
+		})
+	}
+}
+
+func mustParseSort(t *testing.T, spec string) []SortField {
+	t.Helper()
+	fields, err := ParseSort(spec)
+	assert.NoError(t, err)
+	return fields
+}
+
+func TestGetCrawlResultsPage_Cursor(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		result := &models.CrawlResult{
+			URL:       fmt.Sprintf("http://example.com/cursor%d", i),
+			PageTitle: fmt.Sprintf("Cursor Page %d", i),
+			// Two results share each timestamp so that the ID tie-breaker is exercised
+			UpdatedAt: base.Add(time.Duration(i/2) * time.Hour),
+		}
+		err = dbInstance.CreateCrawlResult(result)
+		assert.NoError(t, err)
+		dbInstance.db.Model(result).UpdateColumn("updated_at", result.UpdatedAt)
+	}
+
+	sort := mustParseSort(t, "-updated_at")
+	titles := func(page *ResultPage) []string {
+		var out []string
+		for _, result := range page.Results {
+			out = append(out, result.PageTitle)
+		}
+		return out
+	}
+
+	first, err := dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: sort})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cursor Page 4", "Cursor Page 2"}, titles(first))
+	assert.Equal(t, int64(5), first.Total)
+	assert.NotEmpty(t, first.Next)
+	assert.Empty(t, first.Prev)
+
+	// A result inserted at the front does not shift the following pages
+	err = dbInstance.CreateCrawlResult(&models.CrawlResult{URL: "http://example.com/cursor-new", PageTitle: "New", UpdatedAt: base.Add(10 * time.Hour)})
+	assert.NoError(t, err)
+
+	second, err := dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: sort, Cursor: first.Next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cursor Page 3", "Cursor Page 0"}, titles(second))
+	assert.NotEmpty(t, second.Next)
+	assert.NotEmpty(t, second.Prev)
+
+	third, err := dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: sort, Cursor: second.Next})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cursor Page 1"}, titles(third))
+	assert.Empty(t, third.Next)
+
+	back, err := dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: sort, Cursor: second.Prev})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Cursor Page 4", "Cursor Page 2"}, titles(back))
+	assert.NotEmpty(t, back.Prev, "the newly inserted result precedes the first page now")
+	assert.NotEmpty(t, back.Next)
+
+	// Cursors are bound to the sort order they were issued for
+	_, err = dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: mustParseSort(t, "url"), Cursor: first.Next})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+
+	_, err = dbInstance.GetCrawlResultsPage(ResultQuery{Limit: 2, Sort: sort, Cursor: "not-a-cursor"})
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+func TestSearchCrawlResults(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	pricing := &models.CrawlResult{
+		URL:             "http://example.com/pricing",
+		Status:          "completed",
+		PageTitle:       "Plans",
+		MetaDescription: "Compare our plans",
+		Outline:         models.HeadingList{{Level: 1, Text: "Pricing for teams"}, {Level: 2, Text: "FAQ"}},
+	}
+	blog := &models.CrawlResult{
+		URL:       "http://example.com/blog/why-we-changed",
+		Status:    "completed",
+		PageTitle: "Blog",
+		Outline:   models.HeadingList{{Level: 1, Text: "Why we changed"}, {Level: 2, Text: "New pricing model"}},
+	}
+	for _, result := range []*models.CrawlResult{pricing, blog} {
+		err = dbInstance.CreateCrawlResult(result)
+		assert.NoError(t, err)
+		err = dbInstance.IndexCrawlResult(result)
+		assert.NoError(t, err)
+	}
+
+	// Re-indexing replaces the document rather than duplicating it
+	err = dbInstance.IndexCrawlResult(pricing)
+	assert.NoError(t, err)
+
+	results, total, err := dbInstance.SearchCrawlResults(SearchQuery{Text: "Pricing"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, results, 2)
+
+	results, total, err = dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing", Fields: []string{"h1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, pricing.ID, results[0].ID)
+
+	_, total, err = dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing blog", Fields: []string{"url", "title"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	results, total, err = dbInstance.SearchCrawlResults(SearchQuery{Text: "compare plans", Fields: []string{"description"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, pricing.ID, results[0].ID)
+
+	// Deleting a result removes it from the index
+	err = dbInstance.DeleteCrawlResult(pricing.ID)
+	assert.NoError(t, err)
+	_, total, err = dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Unindexing a result, e.g. after a failed re-crawl, removes it from the index
+	err = dbInstance.UnindexCrawlResult(blog.ID)
+	assert.NoError(t, err)
+	_, total, err = dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestSearchCrawlResults_Backfill(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	// Results crawled before the search index existed have no search document
+	completed := &models.CrawlResult{URL: "http://example.com/pricing", Status: "completed", PageTitle: "Pricing"}
+	failed := &models.CrawlResult{URL: "http://example.com/pricing-old", Status: "error"}
+	for _, result := range []*models.CrawlResult{completed, failed} {
+		err = dbInstance.CreateCrawlResult(result)
+		assert.NoError(t, err)
+	}
+	results, _, err := dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing"})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	err = dbInstance.backfillSearchDocuments()
+	assert.NoError(t, err)
+	results, total, err := dbInstance.SearchCrawlResults(SearchQuery{Text: "pricing"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, completed.ID, results[0].ID)
+}
+
+func TestSplitFullTextTerms(t *testing.T) {
+	dbInstance := &DB{}
+	indexed, unindexed := dbInstance.splitFullTextTerms([]string{"go", "pricing", "the", "faq", "für"})
+	assert.Equal(t, []string{"pricing", "faq", "für"}, indexed)
+	assert.Equal(t, []string{"go", "the"}, unindexed)
+
+	dbInstance.fullTextMinLength = 4
+	indexed, unindexed = dbInstance.splitFullTextTerms([]string{"faq", "plans"})
+	assert.Equal(t, []string{"plans"}, indexed)
+	assert.Equal(t, []string{"faq"}, unindexed)
+}
+
+func TestLinkStatus(t *testing.T) {
+	dbInstance, err := NewDBForTest()
+	assert.NoError(t, err)
+	defer dbInstance.Close()
+
+	checkedAt := time.Now().Truncate(time.Second)
+	_, _, found, err := dbInstance.GetLinkStatus("http://example.com/about", checkedAt.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, dbInstance.SaveLinkStatus("http://example.com/about", 404, checkedAt.Add(-time.Hour)))
+	// Saving again replaces the earlier status
+	assert.NoError(t, dbInstance.SaveLinkStatus("http://example.com/about", 200, checkedAt))
+
+	status, gotCheckedAt, found, err := dbInstance.GetLinkStatus("http://example.com/about", checkedAt.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 200, status)
+	assert.True(t, checkedAt.Equal(gotCheckedAt))
+
+	// Statuses checked before notBefore are not returned
+	_, _, found, err = dbInstance.GetLinkStatus("http://example.com/about", checkedAt.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// Statuses checked before the cutoff are deleted
+	deleted, err := dbInstance.DeleteLinkStatuses(checkedAt.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = dbInstance.DeleteLinkStatuses(checkedAt.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sortableColumns whitelists the columns a result listing may be sorted by, together with
+// how to read each column from a result (used to build keyset cursors).
+// Sort specifications are only ever translated through this map, never passed to SQL verbatim.
+var sortableColumns = map[string]func(r *models.CrawlResult) any{
+	"id":                           func(r *models.CrawlResult) any { return r.ID },
+	"created_at":                   func(r *models.CrawlResult) any { return r.CreatedAt },
+	"updated_at":                   func(r *models.CrawlResult) any { return r.UpdatedAt },
+	"url":                          func(r *models.CrawlResult) any { return r.URL },
+	"status":                       func(r *models.CrawlResult) any { return r.Status },
+	"page_title":                   func(r *models.CrawlResult) any { return r.PageTitle },
+	"html_version":                 func(r *models.CrawlResult) any { return r.HTMLVersion },
+	"internal_links_count":         func(r *models.CrawlResult) any { return r.InternalLinksCount },
+	"external_links_count":         func(r *models.CrawlResult) any { return r.ExternalLinksCount },
+	"inaccessible_links_count":     func(r *models.CrawlResult) any { return r.InaccessibleLinksCount },
+	"inaccessible_resources_count": func(r *models.CrawlResult) any { return r.InaccessibleResourcesCount },
+	"has_login_form":               func(r *models.CrawlResult) any { return r.HasLoginForm },
+}
+
+// SortField is a single whitelisted column of a sort specification.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// TagFilter restricts a result listing to results carrying the given tags.
+// With MatchAll set a result must carry every tag (AND), otherwise any of them (OR).
+type TagFilter struct {
+	Names    []string
+	MatchAll bool
+}
+
+// ResultFilter holds the structured filters of a result listing. Zero values mean "no filter".
+type ResultFilter struct {
+	URL            string
+	Title          string
+	Statuses       []string
+	HTMLVersions   []string
+	HasLoginForm   *bool
+	MinBrokenLinks *int
+	MaxBrokenLinks *int
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	UpdatedBefore  *time.Time
+}
+
+// ResultQuery describes a filtered, sorted and paginated listing of crawl results.
+// When Cursor is set the listing continues from that keyset position and Offset is ignored.
+type ResultQuery struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   []SortField
+	Filter ResultFilter
+	Tags   TagFilter
+}
+
+// ParseSort parses a comma-separated sort specification such as "-updated_at,url".
+// A leading "-" sorts descending; the legacy "column desc" form is accepted as well.
+// Unknown or repeated columns are rejected.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{}
+		name := part
+		if words := strings.Fields(part); len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				field.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q", words[1])
+			}
+			name = words[0]
+		} else if strings.HasPrefix(part, "-") {
+			field.Desc = true
+			name = part[1:]
+		} else {
+			name = strings.TrimPrefix(part, "+")
+		}
+
+		if _, ok := sortableColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("sort field %q given more than once", name)
+		}
+		seen[name] = true
+		field.Column = name
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern returns a LIKE pattern matching values that contain s literally.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// likeCondition returns a LIKE condition on column using a backslash as the escape character,
+// which MySQL string literals need escaped themselves.
+func likeCondition(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == "mysql" {
+		return column + ` LIKE ? ESCAPE '\\'`
+	}
+	return column + ` LIKE ? ESCAPE '\'`
+}
+
+// applyFilter narrows a CrawlResult query by the structured filters.
+func applyFilter(query *gorm.DB, filter ResultFilter) *gorm.DB {
+	if filter.URL != "" {
+		query = query.Where(likeCondition(query, "url"), containsPattern(filter.URL))
+	}
+	if filter.Title != "" {
+		query = query.Where(likeCondition(query, "page_title"), containsPattern(filter.Title))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.HTMLVersions) > 0 {
+		query = query.Where("html_version IN ?", filter.HTMLVersions)
+	}
+	if filter.HasLoginForm != nil {
+		query = query.Where("has_login_form = ?", *filter.HasLoginForm)
+	}
+	if filter.MinBrokenLinks != nil {
+		query = query.Where("inaccessible_links_count >= ?", *filter.MinBrokenLinks)
+	}
+	if filter.MaxBrokenLinks != nil {
+		query = query.Where("inaccessible_links_count <= ?", *filter.MaxBrokenLinks)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+	return query
+}
+
+// applySort orders a query by the given whitelisted fields.
+func applySort(query *gorm.DB, fields []SortField) *gorm.DB {
+	for _, field := range fields {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: field.Column}, Desc: field.Desc})
+	}
+	return query
+}
+
+// withTiebreaker appends the ID to a sort specification, unless already present,
+// so that the order is total and pages are stable.
+func withTiebreaker(fields []SortField) []SortField {
+	for _, field := range fields {
+		if field.Column == "id" {
+			return fields
+		}
+	}
+	return append(append([]SortField{}, fields...), SortField{Column: "id"})
+}
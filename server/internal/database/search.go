@@ -0,0 +1,215 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/krzysu/website-analyzer/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchFields maps the searchable fields to their columns in the search_documents table.
+var searchFields = map[string]string{
+	"url":         "search_documents.url",
+	"title":       "search_documents.title",
+	"h1":          "search_documents.h1",
+	"headings":    "search_documents.headings",
+	"description": "search_documents.description",
+}
+
+// SearchFields lists the fields a search can be restricted to, in a stable order.
+var SearchFields = []string{"url", "title", "h1", "headings", "description"}
+
+// searchIndexes are the MySQL full-text indexes. MATCH() requires an index covering exactly
+// the searched columns, so there is one for all fields together and one per field.
+var searchIndexes = map[string][]string{
+	"idx_search_all":         {"url", "title", "h1", "headings", "description"},
+	"idx_search_url":         {"url"},
+	"idx_search_title":       {"title"},
+	"idx_search_h1":          {"h1"},
+	"idx_search_headings":    {"headings"},
+	"idx_search_description": {"description"},
+}
+
+// fullTextMinLength is the default shortest word MySQL indexes (innodb_ft_min_token_size).
+const fullTextMinLength = 3
+
+// fullTextStopwords are the words of the default InnoDB stopword list, which are not indexed.
+var fullTextStopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"com": true, "de": true, "en": true, "for": true, "from": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "la": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true, "where": true, "who": true,
+	"will": true, "with": true, "und": true, "www": true,
+}
+
+// SearchQuery describes a full-text search over crawl results.
+// Fields restricts the search to some of SearchFields; empty means all of them.
+type SearchQuery struct {
+	Text   string
+	Fields []string
+	Limit  int
+	Offset int
+}
+
+// migrateSearchIndexes creates the MySQL full-text indexes on search_documents.
+func migrateSearchIndexes(gormDB *gorm.DB) error {
+	for name, columns := range searchIndexes {
+		if gormDB.Migrator().HasIndex(&models.SearchDocument{}, name) {
+			continue
+		}
+		sql := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON search_documents (%s)", name, strings.Join(columns, ", "))
+		if err := gormDB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create full-text index %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadFullTextMinLength reads the shortest word the MySQL server indexes, keeping the default if
+// it cannot be read.
+func loadFullTextMinLength(gormDB *gorm.DB) int {
+	var minLength int
+	if err := gormDB.Raw("SELECT @@innodb_ft_min_token_size").Scan(&minLength).Error; err != nil || minLength <= 0 {
+		log.Printf("Could not read innodb_ft_min_token_size, assuming %d: %v", fullTextMinLength, err)
+		return fullTextMinLength
+	}
+	return minLength
+}
+
+// backfillSearchDocuments indexes the completed crawl results that have no search document,
+// such as those crawled before the search_documents table existed.
+func (d *DB) backfillSearchDocuments() error {
+	var results []*models.CrawlResult
+	return d.db.Where("status = ?", "completed").
+		Where("NOT EXISTS (SELECT 1 FROM search_documents WHERE search_documents.crawl_result_id = crawl_results.id)").
+		FindInBatches(&results, 100, func(tx *gorm.DB, batch int) error {
+			for _, result := range results {
+				if err := d.IndexCrawlResult(result); err != nil {
+					return fmt.Errorf("failed to index crawl result %d: %w", result.ID, err)
+				}
+			}
+			return nil
+		}).Error
+}
+
+// IndexCrawlResult creates or refreshes the search document of a CrawlResult.
+func (d *DB) IndexCrawlResult(result *models.CrawlResult) error {
+	doc := &models.SearchDocument{
+		CrawlResultID: result.ID,
+		URL:           result.URL,
+		Title:         result.PageTitle,
+		Description:   result.MetaDescription,
+	}
+
+	var h1, headings []string
+	for _, heading := range result.Outline {
+		if heading.Level == 1 {
+			h1 = append(h1, heading.Text)
+		}
+		headings = append(headings, heading.Text)
+	}
+	doc.H1 = strings.Join(h1, "\n")
+	doc.Headings = strings.Join(headings, "\n")
+
+	return d.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(doc).Error
+}
+
+// UnindexCrawlResult removes the search document of a CrawlResult, e.g. when a re-crawl fails.
+func (d *DB) UnindexCrawlResult(id uint) error {
+	return d.db.Delete(&models.SearchDocument{}, "crawl_result_id = ?", id).Error
+}
+
+// SearchCrawlResults finds the crawl results whose indexed text contains every word of the query.
+// MySQL uses the full-text indexes and orders by relevance; words the indexes leave out, too
+// short or stopwords, are matched with LIKE instead. Other databases fall back to LIKE matching
+// ordered by recency.
+func (d *DB) SearchCrawlResults(q SearchQuery) ([]*models.CrawlResult, int64, error) {
+	var results []*models.CrawlResult
+	var total int64
+
+	terms := searchTerms(q.Text)
+	if len(terms) == 0 {
+		return results, 0, nil
+	}
+
+	fields := q.Fields
+	if len(fields) == 0 {
+		fields = SearchFields
+	}
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		column, ok := searchFields[field]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown search field %q", field)
+		}
+		columns = append(columns, column)
+	}
+
+	query := d.db.Model(&models.CrawlResult{}).
+		Joins("JOIN search_documents ON search_documents.crawl_result_id = crawl_results.id")
+
+	var order any = "crawl_results.updated_at DESC, crawl_results.id DESC"
+	likeTerms := terms
+	if d.db.Dialector.Name() == "mysql" {
+		var indexed []string
+		indexed, likeTerms = d.splitFullTextTerms(terms)
+		if len(indexed) > 0 {
+			// Every term is required, and matches word prefixes like the LIKE fallback does
+			boolean := "+" + strings.Join(indexed, "* +") + "*"
+			match := "MATCH(" + strings.Join(columns, ", ") + ") AGAINST (? IN BOOLEAN MODE)"
+			query = query.Where(match, boolean)
+			order = clause.OrderBy{Expression: clause.Expr{SQL: match + " DESC, crawl_results.id DESC", Vars: []any{boolean}}}
+		}
+	}
+	for _, term := range likeTerms {
+		var conditions []string
+		var args []any
+		for _, column := range columns {
+			conditions = append(conditions, column+" LIKE ?")
+			args = append(args, "%"+term+"%")
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = applyPagination(query.Order(order), q.Limit, q.Offset)
+	if err := query.Preload("Tags").Find(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// splitFullTextTerms separates the terms the full-text indexes can match from those they leave
+// out: words shorter than the shortest indexed word, and stopwords. A required term that is not
+// indexed would match no document at all.
+func (d *DB) splitFullTextTerms(terms []string) (indexed, unindexed []string) {
+	minLength := d.fullTextMinLength
+	if minLength == 0 {
+		minLength = fullTextMinLength
+	}
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minLength || fullTextStopwords[term] {
+			unindexed = append(unindexed, term)
+		} else {
+			indexed = append(indexed, term)
+		}
+	}
+	return indexed, unindexed
+}
+
+// searchTerms splits a search query into lowercase words, dropping punctuation so that
+// the terms are safe to embed in a MySQL boolean-mode expression.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
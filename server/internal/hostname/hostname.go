@@ -0,0 +1,78 @@
+// Package hostname normalizes and matches host names, so that hosts submitted through the API,
+// found in crawled pages and listed in the network policy are compared the same way.
+package hostname
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// profile converts internationalized host names to punycode, checking the DNS length limits of
+// 253 characters per name and 63 per label.
+var profile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.VerifyDNSLength(true))
+
+// Normalize lowercases a host and removes surrounding whitespace, IPv6 brackets and a trailing dot.
+func Normalize(host string) string {
+	host = strings.TrimSpace(host)
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// ToASCII normalizes a host and validates it, converting an internationalized name to punycode
+// and an IP address to its canonical form.
+func ToASCII(host string) (string, error) {
+	host = Normalize(host)
+	if host == "" {
+		return "", errors.New("the host is empty")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	return profile.ToASCII(host)
+}
+
+// ParsePattern validates and normalizes a host pattern, a host name, an IP address or "*."
+// followed by a domain.
+func ParsePattern(pattern string) (string, error) {
+	pattern = strings.TrimSpace(pattern)
+	switch {
+	case strings.Contains(pattern, "/"):
+		return "", errors.New("must be a host name, not a URL")
+	case net.ParseIP(Normalize(pattern)) == nil && strings.Contains(pattern, ":"):
+		return "", errors.New("must not include a port")
+	}
+	domain, wildcard := strings.CutPrefix(pattern, "*.")
+	host, err := ToASCII(domain)
+	if err != nil {
+		return "", err
+	}
+	if wildcard {
+		if net.ParseIP(host) != nil {
+			return "", errors.New("a wildcard must be followed by a domain")
+		}
+		return "*." + host, nil
+	}
+	return host, nil
+}
+
+// Match reports whether a normalized host matches pattern, a host name or "*." followed by a
+// domain, which matches the domain and its subdomains.
+func Match(pattern, host string) bool {
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// MatchAny reports whether a normalized host matches one of patterns.
+func MatchAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package hostname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "example.com", Normalize(" Example.COM. "))
+	assert.Equal(t, "::1", Normalize("[::1]"))
+}
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+		err      string
+	}{
+		{host: "Bücher.Example.", expected: "xn--bcher-kva.example"},
+		{host: "[2001:DB8::1]", expected: "2001:db8::1"},
+		{host: "127.0.0.1", expected: "127.0.0.1"},
+		{host: " ", err: "the host is empty"},
+		{host: "exa_mple.com", err: "idna: disallowed rune U+005F"},
+		{host: strings.Repeat("a", 64) + ".com", err: "idna: invalid label"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			host, err := ToASCII(tt.host)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, host)
+		})
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		err      string
+	}{
+		{pattern: " CDN.Example.com ", expected: "cdn.example.com"},
+		{pattern: "*.Bücher.example", expected: "*.xn--bcher-kva.example"},
+		{pattern: "::1", expected: "::1"},
+		{pattern: "", err: "the host is empty"},
+		{pattern: "*.", err: "the host is empty"},
+		{pattern: "https://example.com", err: "must be a host name, not a URL"},
+		{pattern: "example.com:8080", err: "must not include a port"},
+		{pattern: "*.10.0.0.1", err: "a wildcard must be followed by a domain"},
+		{pattern: "ex*ample.com", err: "idna: disallowed rune"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			pattern, err := ParsePattern(tt.pattern)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, pattern)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("example.com", "example.com"))
+	assert.False(t, Match("example.com", "www.example.com"))
+	assert.True(t, Match("*.example.com", "example.com"))
+	assert.True(t, Match("*.example.com", "a.b.example.com"))
+	assert.False(t, Match("*.example.com", "notexample.com"))
+	assert.True(t, MatchAny([]string{"other.org", "*.example.com"}, "cdn.example.com"))
+	assert.False(t, MatchAny(nil, "example.com"))
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// AccessibilityReport holds the results of the accessibility checks of a page.
+// Counts has the number of offending elements per rule; Issues has snippets of
+// the first few offending elements of each rule.
+type AccessibilityReport struct {
+	Counts map[string]int       `json:"counts"`
+	Issues []AccessibilityIssue `json:"issues"`
+}
+
+// AccessibilityIssue is a single element violating an accessibility rule.
+type AccessibilityIssue struct {
+	Rule    string `json:"rule"`
+	Snippet string `json:"snippet"`
+}
+
+// Value implements the driver.Valuer interface for AccessibilityReport.
+func (a AccessibilityReport) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan implements the sql.Scanner interface for AccessibilityReport.
+func (a *AccessibilityReport) Scan(src interface{}) error {
+	if src == nil {
+		*a = AccessibilityReport{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, a)
+}
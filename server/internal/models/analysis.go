@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Link scopes, deciding which links of a page count as internal.
+const (
+	LinkScopeHost       = "host"       // the page's own host only
+	LinkScopeSubdomains = "subdomains" // the page's host and its subdomains
+	LinkScopeDomain     = "domain"     // any host of the page's registrable domain, e.g. example.co.uk
+)
+
+// CrawlOptions are the per-job settings of a crawl. They are stored with the
+// result so that re-runs analyze the page the same way.
+type CrawlOptions struct {
+	// Analyzers enables (true) or disables (false) analyzers by name,
+	// overriding whether they run by default.
+	Analyzers map[string]bool `json:"analyzers,omitempty"`
+	// ProbeResources requests the size of every resource of the page to estimate its total weight.
+	ProbeResources bool `json:"probeResources,omitempty"`
+	// LinkScope is one of the LinkScope constants; empty means LinkScopeHost.
+	LinkScope string `json:"linkScope,omitempty"`
+	// InternalHosts lists hosts whose links are internal regardless of the scope.
+	// A leading "*." matches the host and its subdomains.
+	InternalHosts []string `json:"internalHosts,omitempty"`
+	// BypassLinkCache checks every link afresh instead of reusing recently checked statuses.
+	BypassLinkCache bool `json:"bypassLinkCache,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for CrawlOptions.
+func (o CrawlOptions) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan implements the sql.Scanner interface for CrawlOptions.
+func (o *CrawlOptions) Scan(src interface{}) error {
+	if src == nil {
+		*o = CrawlOptions{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, o)
+}
+
+// Section is the output of a single analyzer. Data holds the analyzer's
+// findings as JSON; Error is set instead if the analyzer failed.
+type Section struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Sections is a custom type for handling the analyzer sections of a result, keyed by analyzer name.
+type Sections map[string]Section
+
+// Value implements the driver.Valuer interface for Sections.
+func (s Sections) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for Sections.
+func (s *Sections) Scan(src interface{}) error {
+	if src == nil {
+		*s = make(map[string]Section)
+		return nil
+	}
+	b, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(b, s)
+}
@@ -0,0 +1,26 @@
+package models
+
+// PDFInfo is the section recorded by the "pdf" analyzer.
+type PDFInfo struct {
+	Version   string `json:"version"`
+	PageCount int    `json:"pageCount"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// ImageInfo is the section recorded by the "image" analyzer. Width and height are
+// zero for formats the analyzer cannot decode.
+type ImageInfo struct {
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// TextInfo is the section recorded by the "text" analyzer. ValidJSON is only set for JSON documents.
+type TextInfo struct {
+	Lines      int   `json:"lines"`
+	Words      int   `json:"words"`
+	Characters int   `json:"characters"`
+	ValidJSON  *bool `json:"validJson,omitempty"`
+}
@@ -15,15 +15,119 @@ type CrawlResult struct {
 	UpdatedAt              time.Time `gorm:"autoUpdateTime"`
 	URL                    string `gorm:"type:text"`
 	Status                 string `gorm:"type:varchar(20)"`
+	HTTPStatusCode         int
+	ContentType            string `gorm:"type:varchar(255)"`
+	ContentSize            int64
+	BodyTruncated          bool
+	Performance            Performance `gorm:"type:json"`
+	TLS                    TLSReport `gorm:"type:json"`
 	PageTitle              string `gorm:"type:varchar(255)"`
 	HTMLVersion            string `gorm:"type:varchar(50)"`
+	RenderingMode          string `gorm:"type:varchar(20)"`
 	Headings               JSONMap `gorm:"type:json"`
+	Outline                HeadingList `gorm:"type:json"`
+	HeadingIssues          HeadingIssues `gorm:"type:json"`
+	MetaDescription        string `gorm:"type:text"`
+	MetaRobots             string `gorm:"type:text"`
+	XRobotsTag             string `gorm:"type:text"`
+	CanonicalURL           string `gorm:"type:text"`
+	Hreflang               HreflangList `gorm:"type:json"`
+	OpenGraph              StringMap `gorm:"type:json"`
+	TwitterCard            StringMap `gorm:"type:json"`
+	Viewport               string `gorm:"type:text"`
+	Charset                string `gorm:"type:text"`
+	DeclaredEncoding       string `gorm:"type:varchar(50)"`
+	DetectedEncoding       string `gorm:"type:varchar(50)"`
+	EncodingMismatch       bool
+	EncodingFindings       FindingList `gorm:"type:json"`
+	Lang                   string `gorm:"type:text"`
+	SEOFindings            FindingList `gorm:"type:json"`
+	StructuredData         StructuredData `gorm:"type:json"`
+	Accessibility          AccessibilityReport `gorm:"type:json"`
+	Security               SecurityReport `gorm:"type:json"`
+	Sections               Sections `gorm:"type:json"`
 	InternalLinksCount     int
 	ExternalLinksCount     int
+	LinkSchemes            JSONMap `gorm:"type:json"`
+	BaseURL                string `gorm:"type:text"`
+	LinkFindings           FindingList `gorm:"type:json"`
 	InaccessibleLinksCount int
 	BrokenLinks            JSONArray `gorm:"type:json"`
+	InaccessibleResourcesCount int
+	BrokenResources        JSONArray `gorm:"type:json"`
+	DanglingAnchors        JSONArray `gorm:"type:json"`
+	LinkCacheStats         LinkCacheStats `gorm:"type:json"`
 	HasLoginForm           bool
+	Forms                  FormInventory `gorm:"type:json"`
 	ErrorMessage           string `gorm:"type:text"`
+	Options                CrawlOptions `gorm:"type:json"`
+	Tags                   []Tag `gorm:"many2many:crawl_result_tags;"`
+}
+
+// Heading is a single heading of a page. Position is its 0-based index in document order.
+type Heading struct {
+	Level    int    `json:"level"`
+	Text     string `json:"text"`
+	Position int    `json:"position"`
+}
+
+// HeadingSkip records a heading that jumps more than one level below the previous one, e.g. H2 to H4.
+type HeadingSkip struct {
+	Position int `json:"position"`
+	From     int `json:"from"`
+	To       int `json:"to"`
+}
+
+// HeadingIssues holds the results of validating a page's heading outline.
+// The slices hold heading positions, or skips, in document order.
+type HeadingIssues struct {
+	MissingH1     bool          `json:"missingH1"`
+	MultipleH1    bool          `json:"multipleH1"`
+	SkippedLevels []HeadingSkip `json:"skippedLevels"`
+	EmptyHeadings []int         `json:"emptyHeadings"`
+	LongHeadings  []int         `json:"longHeadings"`
+}
+
+// Value implements the driver.Valuer interface for HeadingIssues.
+func (h HeadingIssues) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
+// Scan implements the sql.Scanner interface for HeadingIssues.
+func (h *HeadingIssues) Scan(src interface{}) error {
+	if src == nil {
+		*h = HeadingIssues{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, h)
+}
+
+// HeadingList is a custom type for handling a JSON array of headings in MySQL.
+type HeadingList []Heading
+
+// Value implements the driver.Valuer interface for HeadingList.
+func (h HeadingList) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan implements the sql.Scanner interface for HeadingList.
+func (h *HeadingList) Scan(src interface{}) error {
+	if src == nil {
+		*h = make([]Heading, 0)
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, h)
 }
 
 // JSONMap is a custom type for handling JSON map[string]int in MySQL.
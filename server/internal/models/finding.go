@@ -0,0 +1,46 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Finding severities, from most to least serious.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a single issue reported by one of the page audits.
+// Code is a stable machine-readable identifier such as "title_too_long".
+type Finding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// FindingList is a custom type for handling a JSON array of findings in MySQL.
+type FindingList []Finding
+
+// Value implements the driver.Valuer interface for FindingList.
+func (f FindingList) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+// Scan implements the sql.Scanner interface for FindingList.
+func (f *FindingList) Scan(src interface{}) error {
+	if src == nil {
+		*f = make([]Finding, 0)
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, f)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Form classifications.
+const (
+	FormLogin      = "login"
+	FormSignup     = "signup"
+	FormSearch     = "search"
+	FormNewsletter = "newsletter"
+	FormContact    = "contact"
+	FormPayment    = "payment"
+	FormOther      = "other"
+)
+
+// FormInventory describes the forms and sign-in entry points of a page.
+type FormInventory struct {
+	Forms []Form `json:"forms"`
+	// PasswordFieldsOutsideForms counts password inputs not belonging to any form,
+	// as used by script-driven login widgets.
+	PasswordFieldsOutsideForms int `json:"passwordFieldsOutsideForms"`
+	// SSOProviders lists the providers of "Sign in with ..." buttons and links, e.g. "google".
+	SSOProviders []string `json:"ssoProviders"`
+}
+
+// Form is a single <form> of a page. Action is resolved against the page URL.
+type Form struct {
+	Action         string      `json:"action"`
+	Method         string      `json:"method"`
+	HTTPSAction    bool        `json:"httpsAction"`
+	HasCSRFToken   bool        `json:"hasCsrfToken"`
+	Fields         []FormField `json:"fields"`
+	Classification string      `json:"classification"`
+}
+
+// FormField is an input, select or textarea of a form.
+type FormField struct {
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for FormInventory.
+func (f FormInventory) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+// Scan implements the sql.Scanner interface for FormInventory.
+func (f *FormInventory) Scan(src interface{}) error {
+	if src == nil {
+		*f = FormInventory{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, f)
+}
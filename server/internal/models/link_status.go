@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// LinkStatus is the status of a checked URL, cached so that the link checkers of all
+// crawler processes check a URL at most once per cache window. URLs that could not be
+// reached are not cached, and expired statuses are deleted by CheckedAt.
+type LinkStatus struct {
+	URLHash    string `gorm:"primaryKey;type:char(64)"` // hex SHA-256 of URL
+	URL        string `gorm:"type:text"`
+	StatusCode int
+	CheckedAt  time.Time `gorm:"index"`
+}
+
+// LinkCacheStats counts how many of the links and resources of a page were served from
+// the link status cache. Bypassed is set when the job skipped the cache.
+type LinkCacheStats struct {
+	Hits     int  `json:"hits"`
+	Misses   int  `json:"misses"`
+	Bypassed bool `json:"bypassed"`
+}
+
+// Value implements the driver.Valuer interface for LinkCacheStats.
+func (s LinkCacheStats) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for LinkCacheStats.
+func (s *LinkCacheStats) Scan(src interface{}) error {
+	if src == nil {
+		*s = LinkCacheStats{}
+		return nil
+	}
+	b, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(b, s)
+}
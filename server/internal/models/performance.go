@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Performance holds the timings and sizes of fetching a page, and an inventory of the
+// resources it references. Timings are in milliseconds; those of phases skipped because a
+// connection was reused are zero.
+type Performance struct {
+	DNSLookupMs       float64 `json:"dnsLookupMs"`
+	ConnectMs         float64 `json:"connectMs"`
+	TLSHandshakeMs    float64 `json:"tlsHandshakeMs"`
+	TimeToFirstByteMs float64 `json:"timeToFirstByteMs"`
+	DownloadMs        float64 `json:"downloadMs"`
+	TotalMs           float64 `json:"totalMs"`
+	Protocol          string  `json:"protocol"`
+	Compression       string  `json:"compression"`
+	// TransferSize is the number of body bytes received, before decompression.
+	TransferSize int64 `json:"transferSize"`
+	// DecodedSize is the number of body bytes after decompression.
+	DecodedSize    int64          `json:"decodedSize"`
+	Resources      []Resource     `json:"resources"`
+	ResourceCounts map[string]int `json:"resourceCounts"`
+	// ProbedResources is the number of resources whose size was read with a HEAD request.
+	ProbedResources int `json:"probedResources"`
+	// PageWeight is the decoded size of the page plus the probed sizes of its resources, all
+	// uncompressed, as resources are probed without accepting a content coding.
+	PageWeight int64 `json:"pageWeight"`
+}
+
+// Resource is a script, stylesheet, image or font referenced by a page. Size is only set
+// when resource sizes were probed and the server reported a Content-Length.
+type Resource struct {
+	URL  string `json:"url"`
+	Kind string `json:"kind"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for Performance.
+func (p Performance) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface for Performance.
+func (p *Performance) Scan(src interface{}) error {
+	if src == nil {
+		*p = Performance{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, p)
+}
@@ -0,0 +1,12 @@
+package models
+
+// SearchDocument holds the searchable text of a crawl result. It lives in its own table so that
+// the full-text indexes on it do not slow down the frequent writes to crawl results.
+type SearchDocument struct {
+	CrawlResultID uint   `gorm:"primarykey;autoIncrement:false"`
+	URL           string `gorm:"type:text"`
+	Title         string `gorm:"type:text"`
+	H1            string `gorm:"type:text"`
+	Headings      string `gorm:"type:text"`
+	Description   string `gorm:"type:text"`
+}
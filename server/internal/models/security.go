@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// SecurityReport holds the results of the security headers and transport audit of a page.
+// Score runs from 0 to 100 and is graded from A to F; Findings explains every deduction.
+type SecurityReport struct {
+	Score        int                   `json:"score"`
+	Grade        string                `json:"grade"`
+	HTTPS        bool                  `json:"https"`
+	Headers      map[string]string     `json:"headers"`
+	CSP          []map[string][]string `json:"csp"` // the enforced policies, each parsed into its directives
+	Cookies      []CookieReport        `json:"cookies"`
+	MixedContent []MixedContent        `json:"mixedContent"`
+	Findings     FindingList           `json:"findings"`
+}
+
+// CookieReport holds the security attributes of a cookie set by a page.
+type CookieReport struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"httpOnly"`
+	SameSite string `json:"sameSite"`
+}
+
+// Mixed content types.
+const (
+	MixedContentActive  = "active"  // blocked by browsers, e.g. scripts and stylesheets
+	MixedContentPassive = "passive" // loaded or upgraded by browsers, e.g. images and media
+	MixedContentForm    = "form"    // a form submitting over plain HTTP
+)
+
+// MixedContent is a resource of an HTTPS page referenced over plain HTTP.
+// Kind is the kind of resource, e.g. "script", and Element the tag and attribute
+// referencing it, e.g. "img srcset".
+type MixedContent struct {
+	URL     string `json:"url"`
+	Kind    string `json:"kind"`
+	Type    string `json:"type"`
+	Element string `json:"element"`
+}
+
+// Value implements the driver.Valuer interface for SecurityReport.
+func (s SecurityReport) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for SecurityReport.
+func (s *SecurityReport) Scan(src interface{}) error {
+	if src == nil {
+		*s = SecurityReport{}
+		return nil
+	}
+	b, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(b, s)
+}
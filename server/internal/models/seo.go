@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// HreflangLink is an alternate language version of a page, from <link rel="alternate" hreflang>.
+type HreflangLink struct {
+	Lang string `json:"lang"`
+	Href string `json:"href"`
+}
+
+// HreflangList is a custom type for handling a JSON array of hreflang alternates in MySQL.
+type HreflangList []HreflangLink
+
+// Value implements the driver.Valuer interface for HreflangList.
+func (h HreflangList) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan implements the sql.Scanner interface for HreflangList.
+func (h *HreflangList) Scan(src interface{}) error {
+	if src == nil {
+		*h = make([]HreflangLink, 0)
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, h)
+}
+
+// StringMap is a custom type for handling JSON map[string]string in MySQL,
+// e.g. Open Graph properties keyed by property name.
+type StringMap map[string]string
+
+// Value implements the driver.Valuer interface for StringMap.
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for StringMap.
+func (m *StringMap) Scan(src interface{}) error {
+	if src == nil {
+		*m = make(map[string]string)
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, m)
+}
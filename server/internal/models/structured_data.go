@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// StructuredData holds the JSON-LD, Microdata and RDFa items found on a page,
+// together with syntax errors and missing required properties.
+type StructuredData struct {
+	Items    []StructuredDataItem `json:"items"`
+	Findings FindingList          `json:"findings"`
+}
+
+// StructuredDataItem is a single top-level entity. Properties holds the entity as parsed;
+// for the supported schema.org types exactly one of the typed fields is set as well.
+type StructuredDataItem struct {
+	Format            string          `json:"format"`
+	Type              string          `json:"type"`
+	Properties        map[string]any  `json:"properties"`
+	MissingProperties []string        `json:"missingProperties,omitempty"`
+	Product           *Product        `json:"product,omitempty"`
+	Article           *Article        `json:"article,omitempty"`
+	BreadcrumbList    *BreadcrumbList `json:"breadcrumbList,omitempty"`
+	Organization      *Organization   `json:"organization,omitempty"`
+	FAQPage           *FAQPage        `json:"faqPage,omitempty"`
+}
+
+// Product is a schema.org Product.
+type Product struct {
+	Name            string           `json:"name"`
+	Description     string           `json:"description,omitempty"`
+	SKU             string           `json:"sku,omitempty"`
+	Brand           string           `json:"brand,omitempty"`
+	Image           []string         `json:"image,omitempty"`
+	Offers          []Offer          `json:"offers,omitempty"`
+	AggregateRating *AggregateRating `json:"aggregateRating,omitempty"`
+}
+
+// Offer is a schema.org Offer of a Product.
+type Offer struct {
+	Price         string `json:"price,omitempty"`
+	PriceCurrency string `json:"priceCurrency,omitempty"`
+	Availability  string `json:"availability,omitempty"`
+	URL           string `json:"url,omitempty"`
+}
+
+// AggregateRating is a schema.org AggregateRating of a Product.
+type AggregateRating struct {
+	RatingValue string `json:"ratingValue"`
+	ReviewCount string `json:"reviewCount,omitempty"`
+}
+
+// Article is a schema.org Article, including subtypes such as NewsArticle and BlogPosting.
+type Article struct {
+	Headline      string   `json:"headline"`
+	Author        []string `json:"author,omitempty"`
+	DatePublished string   `json:"datePublished,omitempty"`
+	DateModified  string   `json:"dateModified,omitempty"`
+	Image         []string `json:"image,omitempty"`
+}
+
+// BreadcrumbList is a schema.org BreadcrumbList.
+type BreadcrumbList struct {
+	Items []BreadcrumbItem `json:"items"`
+}
+
+// BreadcrumbItem is a single ListItem of a BreadcrumbList.
+type BreadcrumbItem struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Organization is a schema.org Organization.
+type Organization struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url,omitempty"`
+	Logo   string   `json:"logo,omitempty"`
+	SameAs []string `json:"sameAs,omitempty"`
+}
+
+// FAQPage is a schema.org FAQPage.
+type FAQPage struct {
+	Questions []FAQ `json:"questions"`
+}
+
+// FAQ is a single Question of an FAQPage with its accepted answer.
+type FAQ struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// Value implements the driver.Valuer interface for StructuredData.
+func (s StructuredData) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface for StructuredData.
+func (s *StructuredData) Scan(src interface{}) error {
+	if src == nil {
+		*s = StructuredData{}
+		return nil
+	}
+	b, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(b, s)
+}
@@ -0,0 +1,13 @@
+package models
+
+// Tag is a free-form label attached to crawl results, e.g. "client:acme" or "env:staging".
+type Tag struct {
+	ID   uint   `gorm:"primarykey"`
+	Name string `gorm:"type:varchar(100);uniqueIndex"`
+}
+
+// TagCount is the number of crawl results carrying a given tag.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
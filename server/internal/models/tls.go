@@ -0,0 +1,45 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// TLSReport holds the negotiated TLS parameters and the leaf certificate of an HTTPS page.
+// ValidationErrors lists why the certificate chain or host name failed verification, if it did.
+type TLSReport struct {
+	Version            string      `json:"version"`
+	CipherSuite        string      `json:"cipherSuite"`
+	Subject            string      `json:"subject"`
+	SANs               []string    `json:"sans"`
+	Issuer             string      `json:"issuer"`
+	NotBefore          time.Time   `json:"notBefore"`
+	NotAfter           time.Time   `json:"notAfter"`
+	DaysUntilExpiry    int         `json:"daysUntilExpiry"`
+	KeyType            string      `json:"keyType"`
+	KeySize            int         `json:"keySize"`
+	SignatureAlgorithm string      `json:"signatureAlgorithm"`
+	Valid              bool        `json:"valid"`
+	ValidationErrors   []string    `json:"validationErrors"`
+	Findings           FindingList `json:"findings"`
+}
+
+// Value implements the driver.Valuer interface for TLSReport.
+func (t TLSReport) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implements the sql.Scanner interface for TLSReport.
+func (t *TLSReport) Scan(src interface{}) error {
+	if src == nil {
+		*t = TLSReport{}
+		return nil
+	}
+	s, ok := src.([]byte)
+	if !ok {
+		return errors.New("Scan source was not []byte")
+	}
+	return json.Unmarshal(s, t)
+}
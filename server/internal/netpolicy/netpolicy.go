@@ -0,0 +1,307 @@
+// Package netpolicy decides which network addresses the crawler may connect to, protecting
+// the server against server-side request forgery: by default, submitted URLs and the links
+// of crawled pages cannot reach private, loopback, link-local or cloud metadata addresses.
+//
+// The policy is enforced when dialing, after DNS resolution, and the connection is made to
+// the checked IP address, so a host name cannot be rebound to a blocked address between the
+// check and the connection.
+package netpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krzysu/website-analyzer/internal/hostname"
+)
+
+// ErrBlocked is wrapped by the errors of connections refused by a Policy.
+var ErrBlocked = errors.New("blocked by network policy")
+
+// BlockedError reports a host or address refused by a Policy.
+type BlockedError struct {
+	Host   string
+	IP     net.IP // nil if the host name itself is denied
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	if e.IP == nil || e.IP.String() == e.Host {
+		return fmt.Sprintf("%s is %s: %s", e.Host, ErrBlocked, e.Reason)
+	}
+	return fmt.Sprintf("%s (%s) is %s: %s", e.Host, e.IP, ErrBlocked, e.Reason)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// rangeSpec is a CIDR range blocked by default and the reason it is blocked.
+type rangeSpec struct {
+	cidr   string
+	reason string
+}
+
+// blockedRange is an address range blocked by default.
+type blockedRange struct {
+	network *net.IPNet
+	reason  string
+}
+
+// defaultBlocked are the ranges that cannot be reached unless allowed explicitly, in the
+// order they are matched: the unspecified and loopback IPv6 addresses come before the
+// IPv4-compatible range containing them.
+var defaultBlocked = mustParseRanges([]rangeSpec{
+	{"0.0.0.0/8", "unspecified address"},
+	{"10.0.0.0/8", "private address"},
+	{"100.64.0.0/10", "shared address space"},
+	{"127.0.0.0/8", "loopback address"},
+	{"169.254.0.0/16", "link-local address"}, // includes the 169.254.169.254 metadata endpoint
+	{"172.16.0.0/12", "private address"},
+	{"192.0.0.0/24", "IETF protocol assignment"},
+	{"192.168.0.0/16", "private address"},
+	{"198.18.0.0/15", "benchmarking address"},
+	{"224.0.0.0/4", "multicast address"},
+	{"240.0.0.0/4", "reserved address"},
+	{"::/128", "unspecified address"},
+	{"::1/128", "loopback address"},
+	{"::/96", "IPv4-compatible address"}, // deprecated ::a.b.c.d form of an IPv4 address
+	{"fc00::/7", "unique local address"}, // includes the fd00:ec2::254 metadata endpoint
+	{"fe80::/10", "link-local address"},
+	{"ff00::/8", "multicast address"},
+	{"64:ff9b:1::/48", "local-use NAT64 address"},
+	{"2001::/32", "Teredo address"}, // embeds an obfuscated IPv4 address
+})
+
+// Prefixes of IPv6 addresses embedding an IPv4 address, which is checked in their place.
+var (
+	nat64Prefix = mustParseCIDR("64:ff9b::/96")
+	sixToFour   = mustParseCIDR("2002::/16")
+)
+
+// defaultDeniedHosts are metadata host names that are always denied, in addition to the
+// addresses they resolve to.
+var defaultDeniedHosts = []string{"metadata.google.internal", "metadata.goog"}
+
+// Policy decides which hosts and addresses may be connected to. Entries of the deny list are
+// always refused. Otherwise hosts and addresses on the allow list are accepted, even within the
+// default blocked ranges, and every other public address is accepted. A nil *Policy behaves as
+// the Default policy.
+type Policy struct {
+	allowNets  []*net.IPNet
+	denyNets   []*net.IPNet
+	allowHosts []string
+	denyHosts  []string
+	resolver   *net.Resolver
+	dialer     *net.Dialer
+}
+
+// New creates a Policy from allow and deny lists. Each entry is a CIDR range ("10.1.0.0/16"),
+// an IP address, a host name, or "*." followed by a domain, which matches the domain and its
+// subdomains.
+func New(allow, deny []string) (*Policy, error) {
+	p := &Policy{
+		denyHosts: append([]string{}, defaultDeniedHosts...),
+		resolver:  net.DefaultResolver,
+		dialer:    &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	var errs []error
+	for _, entry := range allow {
+		if err := addEntry(entry, &p.allowNets, &p.allowHosts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, entry := range deny {
+		if err := addEntry(entry, &p.denyNets, &p.denyHosts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return p, errors.Join(errs...)
+}
+
+// defaultPolicy is used in place of a nil *Policy.
+var defaultPolicy = Default()
+
+// Default returns the policy blocking the default ranges, with empty allow and deny lists.
+func Default() *Policy {
+	p, _ := New(nil, nil)
+	return p
+}
+
+// FromEnv creates a Policy from the comma-separated lists in the environment variables
+// NETWORK_ALLOWLIST and NETWORK_DENYLIST. Invalid entries are logged and ignored.
+func FromEnv() *Policy {
+	p, err := New(splitList(os.Getenv("NETWORK_ALLOWLIST")), splitList(os.Getenv("NETWORK_DENYLIST")))
+	if err != nil {
+		log.Printf("Invalid network policy entries ignored: %v", err)
+	}
+	return p
+}
+
+// CheckHost checks a host name or IP address literal without resolving it, so it only
+// catches hosts that are refused whatever they resolve to. The address actually connected
+// to is checked by DialContext.
+func (p *Policy) CheckHost(host string) error {
+	if p == nil {
+		p = defaultPolicy
+	}
+	host = hostname.Normalize(host)
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(host, ip)
+	}
+	if hostname.MatchAny(p.denyHosts, host) {
+		return &BlockedError{Host: host, Reason: "denied host"}
+	}
+	return nil
+}
+
+// DialContext resolves the host of address and connects to the first of its IP addresses
+// allowed by the policy. It can be used as the DialContext of an http.Transport.
+func (p *Policy) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if p == nil {
+		p = defaultPolicy
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	host = hostname.Normalize(host)
+	if err := p.CheckHost(host); err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	var firstErr error
+	for _, ip := range ips {
+		err := p.checkIP(host, ip)
+		if err == nil {
+			var conn net.Conn
+			if conn, err = p.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+				return conn, nil
+			}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, firstErr
+}
+
+// checkIP checks an address that host resolved to.
+func (p *Policy) checkIP(host string, ip net.IP) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4 // IPv4-mapped IPv6 addresses are checked as IPv4
+	}
+	if containsIP(p.denyNets, ip) {
+		return &BlockedError{Host: host, IP: ip, Reason: "denied address"}
+	}
+	if hostname.MatchAny(p.allowHosts, host) || containsIP(p.allowNets, ip) {
+		return nil
+	}
+	if embedded := embeddedIPv4(ip); embedded != nil {
+		return p.checkIP(host, embedded)
+	}
+	for _, blocked := range defaultBlocked {
+		if blocked.network.Contains(ip) {
+			return &BlockedError{Host: host, IP: ip, Reason: blocked.reason}
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address embedded in a NAT64 (64:ff9b::/96) or 6to4
+// (2002::/16) address, through which the IPv4 address can be reached, or nil.
+func embeddedIPv4(ip net.IP) net.IP {
+	switch {
+	case len(ip) != net.IPv6len:
+		return nil
+	case nat64Prefix.Contains(ip):
+		return net.IPv4(ip[12], ip[13], ip[14], ip[15]).To4()
+	case sixToFour.Contains(ip):
+		return net.IPv4(ip[2], ip[3], ip[4], ip[5]).To4()
+	default:
+		return nil
+	}
+}
+
+// addEntry parses a list entry into a range or a host pattern.
+func addEntry(entry string, nets *[]*net.IPNet, hosts *[]string) error {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return nil
+	}
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		*nets = append(*nets, network)
+		return nil
+	}
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		*nets = append(*nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		return nil
+	}
+	pattern, err := hostname.ParsePattern(entry)
+	if err != nil {
+		return fmt.Errorf("invalid entry %q: %v", entry, err)
+	}
+	*hosts = append(*hosts, pattern)
+	return nil
+}
+
+// containsIP reports whether ip is in one of nets.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, network := range nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-separated list.
+func splitList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+// mustParseRanges parses the default blocked ranges.
+func mustParseRanges(ranges []rangeSpec) []blockedRange {
+	var parsed []blockedRange
+	for _, r := range ranges {
+		parsed = append(parsed, blockedRange{network: mustParseCIDR(r.cidr), reason: r.reason})
+	}
+	return parsed
+}
+
+// mustParseCIDR parses a CIDR range known to be valid.
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
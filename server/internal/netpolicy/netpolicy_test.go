@@ -0,0 +1,107 @@
+package netpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHost(t *testing.T) {
+	policy, err := New(
+		[]string{"10.1.0.0/16", "192.168.1.10", "*.intranet.example"},
+		[]string{"203.0.113.0/24", "evil.example", "10.1.2.3"},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		host   string
+		reason string // empty if allowed
+	}{
+		{host: "93.184.216.34"},
+		{host: "2606:2800:220:1:248:1893:25c8:1946"},
+		{host: "example.com"},
+		{host: "127.0.0.1", reason: "loopback address"},
+		{host: "[::1]", reason: "loopback address"},
+		{host: "::ffff:127.0.0.1", reason: "loopback address"},
+		{host: "169.254.169.254", reason: "link-local address"},
+		{host: "10.0.0.1", reason: "private address"},
+		{host: "172.16.5.4", reason: "private address"},
+		{host: "192.168.0.1", reason: "private address"},
+		{host: "100.100.100.200", reason: "shared address space"},
+		{host: "0.0.0.0", reason: "unspecified address"},
+		{host: "fd00:ec2::254", reason: "unique local address"},
+		{host: "fe80::1", reason: "link-local address"},
+		{host: "64:ff9b::a9fe:a9fe", reason: "link-local address"},
+		{host: "64:ff9b::808:808"},
+		{host: "2002:7f00:1::", reason: "loopback address"},
+		{host: "2002:a01:203::1", reason: "denied address"},
+		{host: "64:ff9b:1::a9fe:a9fe", reason: "local-use NAT64 address"},
+		{host: "2001:0:4136:e378:8000:63bf:3fff:fdd2", reason: "Teredo address"},
+		{host: "::7f00:1", reason: "IPv4-compatible address"},
+		{host: "::a9fe:a9fe", reason: "IPv4-compatible address"},
+		{host: "::808:808", reason: "IPv4-compatible address"},
+		{host: "::", reason: "unspecified address"},
+		{host: "metadata.google.internal", reason: "denied host"},
+		{host: "Metadata.Google.Internal.", reason: "denied host"},
+		// Allow-listed ranges and addresses
+		{host: "10.1.200.7"},
+		{host: "192.168.1.10"},
+		// The deny list wins over the allow list and public addresses
+		{host: "10.1.2.3", reason: "denied address"},
+		{host: "203.0.113.9", reason: "denied address"},
+		{host: "evil.example", reason: "denied host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			err := policy.CheckHost(tt.host)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var blocked *BlockedError
+			require.ErrorAs(t, err, &blocked)
+			assert.Equal(t, tt.reason, blocked.Reason)
+			assert.ErrorIs(t, err, ErrBlocked)
+		})
+	}
+}
+
+func TestNew_InvalidEntries(t *testing.T) {
+	_, err := New([]string{"10.0.0.0/33", " ", "ok.example"}, []string{"http://bad.example/"})
+	assert.ErrorContains(t, err, `invalid entry "10.0.0.0/33"`)
+	assert.ErrorContains(t, err, `invalid entry "http://bad.example/"`)
+}
+
+func TestDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	_, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	// Host names are checked after resolution, so localhost is blocked as a loopback address
+	policy, err := New(nil, nil)
+	require.NoError(t, err)
+	_, err = policy.DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	var blocked *BlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, "localhost", blocked.Host)
+	assert.True(t, blocked.IP.IsLoopback())
+
+	// An HTTP client dialing through the policy refuses the request
+	client := &http.Client{Transport: &http.Transport{DialContext: policy.DialContext}}
+	_, err = client.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrBlocked))
+
+	// Allow-listed hosts can be reached
+	policy, err = New([]string{"localhost"}, nil)
+	require.NoError(t, err)
+	conn, err := policy.DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	require.NoError(t, err)
+	conn.Close()
+}
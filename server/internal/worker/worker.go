@@ -23,16 +23,18 @@ type Worker struct {
 	JobChannel chan Job
 	quit       chan bool
 	db         *database.DB
+	crawler    *crawler.Crawler
 	wg         *sync.WaitGroup // Add WaitGroup to Worker
 }
 
 // NewWorker creates a new Worker.
-func NewWorker(workerPool chan chan Job, db *database.DB, wg *sync.WaitGroup) Worker {
+func NewWorker(workerPool chan chan Job, db *database.DB, c *crawler.Crawler, wg *sync.WaitGroup) Worker {
 	return Worker{
 		WorkerPool: workerPool,
 		JobChannel: make(chan Job),
 		quit:       make(chan bool),
 		db:         db,
+		crawler:    c,
 		wg:         wg,
 	}
 }
@@ -66,20 +68,25 @@ func (w Worker) processJob(job Job) {
 
 	if job.ID != 0 {
 		// If ID is provided, it's a re-crawl, so fetch existing result
-		result, err = w.db.GetCrawlResult(job.ID)
+		existing, err := w.db.GetCrawlResult(job.ID)
 		if err != nil {
 			log.Printf("Error getting existing crawl result for ID %d: %v\n", job.ID, err)
 			return
 		}
-		// Reset only countable fields for re-analysis
-		result.Status = "running"
-		result.ErrorMessage = ""
-		result.Headings = make(map[string]int)
-		result.InternalLinksCount = 0
-		result.ExternalLinksCount = 0
-		result.InaccessibleLinksCount = 0
-		result.BrokenLinks = make([]map[string]interface{}, 0)
-		result.UpdatedAt = time.Now()
+		// Start the re-analysis from a clean result, keeping only its identity, tags and options
+		result = &models.CrawlResult{
+			ID:              existing.ID,
+			CreatedAt:       existing.CreatedAt,
+			UpdatedAt:       time.Now(),
+			URL:             existing.URL,
+			Status:          "running",
+			Headings:        make(map[string]int),
+			BrokenLinks:     make([]map[string]interface{}, 0),
+			BrokenResources: make([]map[string]interface{}, 0),
+			DanglingAnchors: make([]map[string]interface{}, 0),
+			Tags:            existing.Tags,
+			Options:         existing.Options,
+		}
 
 		if err := w.db.UpdateCrawlResult(result); err != nil {
 			log.Printf("Error updating crawl result for ID %d: %v\n", result.ID, err)
@@ -100,7 +107,7 @@ func (w Worker) processJob(job Job) {
 		}
 	}
 
-	crawlErr := crawler.Crawl(result)
+	crawlErr := w.crawler.Crawl(result)
 	if crawlErr != nil {
 		log.Printf("Error crawling URL %s: %v\n", job.URL, crawlErr)
 		result.Status = "error"
@@ -111,6 +118,16 @@ func (w Worker) processJob(job Job) {
 	result.UpdatedAt = time.Now()
 	if err := w.db.UpdateCrawlResult(result); err != nil {
 		log.Printf("Error updating crawl result for URL %s: %v\n", result.URL, err)
+		return
+	}
+
+	// Refresh the full-text search index, dropping results whose re-crawl failed
+	if result.Status == "completed" {
+		if err := w.db.IndexCrawlResult(result); err != nil {
+			log.Printf("Error indexing crawl result for URL %s: %v\n", result.URL, err)
+		}
+	} else if err := w.db.UnindexCrawlResult(result.ID); err != nil {
+		log.Printf("Error removing crawl result for URL %s from the search index: %v\n", result.URL, err)
 	}
 }
 
@@ -125,25 +142,38 @@ type Dispatcher struct {
 	WorkerPool chan chan Job
 	JobQueue   chan Job // Add JobQueue to Dispatcher
 	db         *database.DB
+	crawler    *crawler.Crawler
 	wg         *sync.WaitGroup // Add WaitGroup to Dispatcher
 }
 
-// NewDispatcher creates a new Dispatcher.
+// NewDispatcher creates a new Dispatcher. Its workers share a crawler configured from the environment.
 func NewDispatcher(maxWorkers int, db *database.DB, wg *sync.WaitGroup) *Dispatcher {
 	return &Dispatcher{
 		maxWorkers: maxWorkers,
 		WorkerPool: make(chan chan Job, maxWorkers),
 		JobQueue:   make(chan Job, 100), // Initialize JobQueue here
 		db:         db,
+		crawler:    newCrawler(db),
 		wg:         wg, // Use the provided WaitGroup
 	}
 }
 
+// newCrawler creates a crawler configured from the environment, sharing link statuses
+// through the database if configured to.
+func newCrawler(db *database.DB) *crawler.Crawler {
+	config := crawler.ConfigFromEnv()
+	c := crawler.New(config)
+	if config.LinkCacheDB {
+		c.SetLinkStatusStore(db)
+	}
+	return c
+}
+
 // Run starts the workers and listens for jobs.
 func (d *Dispatcher) Run() {
 	// Start the workers
 	for i := 0; i < d.maxWorkers; i++ {
-		worker := NewWorker(d.WorkerPool, d.db, d.wg)
+		worker := NewWorker(d.WorkerPool, d.db, d.crawler, d.wg)
 		worker.Start()
 	}
 
@@ -1,6 +1,9 @@
 package worker
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -12,6 +15,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	// The test servers listen on the loopback address, which the network policy blocks by default
+	os.Setenv("NETWORK_ALLOWLIST", "127.0.0.0/8,::1")
+	os.Exit(m.Run())
+}
+
 func TestWorker_RecrawlResetsCountableFields(t *testing.T) {
 	// 1. Create a mock HTTP server
 	ts := testutils.NewComplexWebsite() // Use the more complex fixture here
@@ -87,7 +96,7 @@ func TestWorker(t *testing.T) {
 
 	// Wait for both jobs to complete by polling the database
 	require.Eventually(t, func() bool {
-		results, err := db.GetCrawlResults(2, 0, "", "")
+		results, err := db.GetCrawlResults(database.ResultQuery{Limit: 2})
 		if err != nil {
 			return false
 		}
@@ -101,7 +110,39 @@ func TestWorker(t *testing.T) {
 	}, 5*time.Second, 50*time.Millisecond)
 
 	// Verify the results in the database
-	results, err := db.GetCrawlResults(2, 0, "", "")
+	results, err := db.GetCrawlResults(database.ResultQuery{Limit: 2})
 	require.NoError(t, err)
 	assert.Len(t, results, 2)
 }
+
+func TestWorker_FailedRecrawlLeavesSearchIndex(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	db, err := database.NewDBForTest()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A result indexed by an earlier, successful crawl
+	result := &models.CrawlResult{URL: ts.URL, Status: "completed", PageTitle: "Pricing"}
+	require.NoError(t, db.CreateCrawlResult(result))
+	require.NoError(t, db.IndexCrawlResult(result))
+
+	var wg sync.WaitGroup
+	dispatcher := NewDispatcher(1, db, &wg)
+	dispatcher.Run()
+	dispatcher.JobQueue <- Job{ID: result.ID, URL: result.URL}
+
+	require.Eventually(t, func() bool {
+		final, err := db.GetCrawlResult(result.ID)
+		return err == nil && final.Status == "error"
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The stale document is removed once the worker has finished with the result
+	require.Eventually(t, func() bool {
+		_, total, err := db.SearchCrawlResults(database.SearchQuery{Text: "pricing"})
+		return err == nil && total == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}